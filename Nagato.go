@@ -14,16 +14,20 @@ import (
 
 // Configuration file contents
 type Configuration struct {
-	Prefix      string
-	OwnerID     string
-	BotToken    string
-	DatabaseURL string
+	Prefix         string
+	OwnerID        string
+	BotToken       string
+	DatabaseURL    string
+	ErrorChannelID string
+	ErrorLogFile   string
+	CommandTimeout int
+	CommandWorkers int
 }
 
 var conf = Configuration{}
 var err = gonfig.GetConf("config.json", &conf)
 var pool = DialNewPool("tcp", ":6379")
-var p = pool.Get()
+var p = pooledConn{pool}
 
 // Create a cache with a default expiration time of 15 minutes, and which
 // purges expired items every 20 minutes
@@ -53,31 +57,79 @@ func main() {
 	// Register the GuildMemberRemove for saying goodbye to members
 	dg.AddHandler(GuildMemberRemove)
 
+	// Register the GuildMembersChunk for caching the member roles of large guilds
+	dg.AddHandler(GuildMembersChunk)
+
 	// Register the MessageDelete for logging deleted messages
 	dg.AddHandler(MessageDelete)
 
+	// Register the MessageDeleteBulk for logging purged messages
+	dg.AddHandler(MessageDeleteBulk)
+
 	// Register the MessageUpdate for logging edited messages
 	dg.AddHandler(MessageUpdate)
 
 	// Register the GuildMemberUpdate for tracking username and nickname changes
 	dg.AddHandler(GuildMemberUpdate)
 
+	// Register the MessageReactionAdd and MessageReactionRemove for onboarding and reaction roles
+	dg.AddHandler(MessageReactionAdd)
+	dg.AddHandler(MessageReactionRemove)
+
+	// Register the audit log handlers for role, channel, ban, emoji, and guild changes
+	dg.AddHandler(GuildRoleCreate)
+	dg.AddHandler(GuildRoleUpdate)
+	dg.AddHandler(GuildRoleDelete)
+	dg.AddHandler(ChannelCreate)
+	dg.AddHandler(ChannelUpdate)
+	dg.AddHandler(ChannelDelete)
+	dg.AddHandler(GuildBanAdd)
+	dg.AddHandler(GuildBanRemove)
+	dg.AddHandler(GuildEmojisUpdate)
+	dg.AddHandler(GuildUpdate)
+
 	// Open a websocket connection to Discord and begin listening.
+	// Check redis is reachable before taking any events, since connections are dialed lazily
+	_, err = p.Do("PING")
+	if err != nil {
+		fmt.Println("error connecting to redis,", err)
+		return
+	}
+
 	err = dg.Open()
 	if err != nil {
 		fmt.Println("error opening connection,", err)
 		return
 	}
 
+	// Start the workers that run commands
+	StartCommandWorkers()
+
+	// Delete queued messages, including those pending from before a restart
+	go StartDeletionQueue(dg)
+
+	// Purge expired cached attachments
+	go func() {
+		for range time.Tick(AttachmentCacheDefaultTime) {
+			PurgeAttachmentCache()
+		}
+	}()
+
 	// Wait here until CTRL-C or other term signal is received.
 	fmt.Println("Nagato is now running. Press CTRL-C to exit.")
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, os.Kill)
 	<-sc
 
-	// Cleanly close down the redis session.
-	p.Close()
-
-	// Cleanly close down the Discord session.
+	// Cleanly close down the Discord session, so no more events use redis.
 	dg.Close()
+
+	// Cancel running commands and wait for them to finish.
+	StopCommandWorkers(ShutdownGracePeriod)
+
+	// Send any batched webhook logs before shutting down.
+	FlushWebhookLogs(dg)
+
+	// Cleanly close down the redis pool.
+	pool.Close()
 }
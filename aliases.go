@@ -0,0 +1,352 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+/**
+ * aliases.go
+ * Chase Weaver
+ *
+ * This package handles per guild command aliases, custom command names and disabled commands.
+ * Built-in names and aliases always win, so guild names can never shadow another command.
+ */
+
+// ProtectedCommands cannot be renamed or disabled, so a guild cannot lock itself out of its settings
+var ProtectedCommands = []string{"command", "config"}
+
+func init() {
+	RegisterNewCommand(Command{
+		Name:            "command",
+		Func:            CommandSettings,
+		Enabled:         true,
+		NSFWOnly:        false,
+		IgnoreSelf:      true,
+		IgnoreBots:      true,
+		Cooldown:        0,
+		RunIn:           []string{"Text"},
+		Aliases:         []string{"cmd"},
+		UserPermissions: []string{"Bot Owner", "Manage Server"},
+		Usage:           []string{},
+		Subcommands: []Command{
+			{
+				Name:        "list",
+				Func:        CommandSettings,
+				Description: "Lists the guild's command aliases, custom names and disabled commands.",
+			},
+			{
+				Name:        "alias",
+				Func:        AddCommandAlias,
+				Params:      []Param{{Name: "alias", Type: ArgString}, {Name: "command", Type: ArgString}},
+				Description: "Adds a guild alias for a command.",
+			},
+			{
+				Name:        "unalias",
+				Func:        RemoveCommandAlias,
+				Params:      []Param{{Name: "alias", Type: ArgString}},
+				Description: "Removes a guild alias.",
+			},
+			{
+				Name:        "rename",
+				Func:        RenameCommand,
+				Params:      []Param{{Name: "command", Type: ArgString}, {Name: "name|reset", Type: ArgString}},
+				Description: "Renames a command in the guild, or resets its name.",
+			},
+			{
+				Name:        "disable",
+				Func:        DisableCommand,
+				Params:      []Param{{Name: "command", Type: ArgString}},
+				Description: "Disables a command in the guild.",
+			},
+			{
+				Name:        "enable",
+				Func:        EnableCommand,
+				Params:      []Param{{Name: "command", Type: ArgString}},
+				Description: "Enables a disabled command in the guild.",
+			},
+		},
+		Description: "Manages guild command aliases, custom names and disabled commands.",
+	})
+}
+
+// CommandSettings :
+// Lists the guild's command aliases, custom names and disabled commands.
+func CommandSettings(ctx Context) {
+
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	var aliases, names []string
+	for k, v := range g.CommandAliases {
+		aliases = append(aliases, fmt.Sprintf("%-12s::  %s", k, v))
+	}
+
+	for k, v := range g.CommandNames {
+		names = append(names, fmt.Sprintf("%-12s::  %s", v, k))
+	}
+
+	sort.Strings(aliases)
+	sort.Strings(names)
+
+	disabled := append([]string{}, g.DisabledCommands...)
+	sort.Strings(disabled)
+
+	str := "== Command Aliases ==\n\n" + strings.Join(aliases, "\n") +
+		"\n\n== Custom Names ==\n\n" + strings.Join(names, "\n") +
+		"\n\n== Disabled Commands ==\n\n" + strings.Join(disabled, ", ")
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, FormatString(str, "asciidoc"))
+}
+
+// AddCommandAlias :
+// Adds a guild alias for a command.
+// [alias] [command]
+func AddCommandAlias(ctx Context) {
+
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	alias := strings.ToLower(ctx.Arguments.String("alias"))
+	cmd := fetchGuildCommand(g, strings.ToLower(ctx.Arguments.String("command")))
+
+	if cmd.isEmpty() {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | `%s` is not a valid command!", ctx.Arguments.String("command")))
+		return
+	}
+
+	if reason := g.CommandNameTaken(alias); len(reason) != 0 {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | "+reason)
+		return
+	}
+
+	if g.CommandAliases == nil {
+		g.CommandAliases = make(map[string]string)
+	}
+	g.CommandAliases[alias] = cmd.Name
+
+	err = PackGuildStruct(ctx.Guild.ID, g)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("✅ | `%s%s` now runs `%s`.", g.GuildPrefix, alias, cmd.Name))
+}
+
+// RemoveCommandAlias :
+// Removes a guild alias.
+// [alias]
+func RemoveCommandAlias(ctx Context) {
+
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	alias := strings.ToLower(ctx.Arguments.String("alias"))
+	if _, ok := g.CommandAliases[alias]; !ok {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | `%s` is not a guild alias.", alias))
+		return
+	}
+
+	delete(g.CommandAliases, alias)
+
+	err = PackGuildStruct(ctx.Guild.ID, g)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("✅ | Removed the alias `%s`.", alias))
+}
+
+// RenameCommand :
+// Renames a command in the guild, or resets it to its built-in name. The built-in name stops
+// working while the built-in aliases keep working.
+// [command] [name|reset]
+func RenameCommand(ctx Context) {
+
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	cmd := fetchGuildCommand(g, strings.ToLower(ctx.Arguments.String("command")))
+	name := strings.ToLower(ctx.Arguments.String("name|reset"))
+
+	if cmd.isEmpty() {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | `%s` is not a valid command!", ctx.Arguments.String("command")))
+		return
+	}
+
+	if Contains(ProtectedCommands, cmd.Name) {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | `%s` cannot be renamed.", cmd.Name))
+		return
+	}
+
+	if name == "reset" || name == cmd.Name {
+		delete(g.CommandNames, cmd.Name)
+	} else {
+		if reason := g.CommandNameTaken(name); len(reason) != 0 {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | "+reason)
+			return
+		}
+
+		if g.CommandNames == nil {
+			g.CommandNames = make(map[string]string)
+		}
+		g.CommandNames[cmd.Name] = name
+	}
+
+	err = PackGuildStruct(ctx.Guild.ID, g)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if _, ok := g.CommandNames[cmd.Name]; !ok {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("✅ | Reset the name of `%s`.", cmd.Name))
+		return
+	}
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("✅ | `%s` is now `%s%s`.", cmd.Name, g.GuildPrefix, name))
+}
+
+// DisableCommand :
+// Disables a command in the guild.
+// [command]
+func DisableCommand(ctx Context) {
+	setCommandsDisabled(ctx, []string{ctx.Arguments.String("command")}, true)
+}
+
+// EnableCommand :
+// Enables a disabled command in the guild.
+// [command]
+func EnableCommand(ctx Context) {
+	setCommandsDisabled(ctx, []string{ctx.Arguments.String("command")}, false)
+}
+
+// setCommandsDisabled :
+// Disables or enables commands by any of their names in the guild.
+func setCommandsDisabled(ctx Context, names []string, disabled bool) {
+
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	err = g.SetCommandsDisabled(names, disabled)
+	if err != nil {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | %s.", err))
+		return
+	}
+
+	err = PackGuildStruct(ctx.Guild.ID, g)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	state := "Enabled"
+	if disabled {
+		state = "Disabled"
+	}
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("✅ | %s `%s`.", state, strings.Join(names, "`, `")))
+}
+
+// SetCommandsDisabled :
+// Disables or enables commands by any of their names. Nothing changes if one is invalid.
+func (g *Guild) SetCommandsDisabled(names []string, disabled bool) error {
+	var resolved []string
+
+	for _, v := range names {
+		cmd := fetchGuildCommand(*g, strings.ToLower(v))
+
+		if cmd.isEmpty() {
+			return fmt.Errorf("`%s` is not a valid command", v)
+		}
+
+		if disabled && Contains(ProtectedCommands, cmd.Name) {
+			return fmt.Errorf("`%s` cannot be disabled", cmd.Name)
+		}
+
+		resolved = append(resolved, cmd.Name)
+	}
+
+	if !disabled {
+		var kept []string
+		for _, v := range g.DisabledCommands {
+			if !Contains(resolved, v) {
+				kept = append(kept, v)
+			}
+		}
+		g.DisabledCommands = kept
+		return nil
+	}
+
+	for _, v := range resolved {
+		if !Contains(g.DisabledCommands, v) {
+			g.DisabledCommands = append(g.DisabledCommands, v)
+		}
+	}
+
+	return nil
+}
+
+// CommandPath :
+// Returns the full name of a command as it is ran in the guild, i.e. with its custom name.
+func (g Guild) CommandPath(c Command) string {
+	path := strings.Fields(c.Path())
+	if len(path) == 0 {
+		return ""
+	}
+
+	if name, ok := g.CommandNames[path[0]]; ok {
+		path[0] = name
+	}
+
+	return strings.Join(path, " ")
+}
+
+// CommandNameTaken :
+// Returns why a name cannot be used as a guild alias, custom name or tag, or nothing if it is free.
+func (g Guild) CommandNameTaken(name string) string {
+
+	if len(name) == 0 || strings.IndexFunc(name, unicode.IsSpace) != -1 {
+		return "Command names cannot be empty or contain spaces."
+	}
+
+	if cmd := FetchBuiltinCommand(name); !cmd.isEmpty() {
+		return fmt.Sprintf("`%s` is already a name or alias of `%s`.", name, cmd.Name)
+	}
+
+	if cmd, ok := g.CommandAliases[name]; ok {
+		return fmt.Sprintf("`%s` is already a guild alias of `%s`.", name, cmd)
+	}
+
+	for k, v := range g.CommandNames {
+		if v == name {
+			return fmt.Sprintf("`%s` is already the guild name of `%s`.", name, k)
+		}
+	}
+
+	if _, ok := g.Tags[name]; ok {
+		return fmt.Sprintf("`%s` is already a tag.", name)
+	}
+
+	return ""
+}
@@ -0,0 +1,633 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+/**
+ * args.go
+ * Chase Weaver
+ *
+ * This package handles splitting command messages into arguments and flags, and typed command
+ * parameters, parsing and validating command arguments before a command is called.
+ */
+
+// ArgType of a command parameter
+type ArgType int
+
+// Command parameter types
+const (
+	ArgString   ArgType = iota // A single word
+	ArgRest                    // The rest of the message
+	ArgInt                     // A whole number
+	ArgDuration                // A duration, i.e. 1h30m
+	ArgEnum                    // One of the parameter's choices
+	ArgUser                    // A user by mention, ID or Name#xxxx
+	ArgMember                  // A guild member by mention, ID or Name#xxxx
+	ArgRole                    // A role by mention, ID or name
+	ArgChannel                 // A channel by mention, ID or name
+)
+
+type (
+
+	// Param declares a typed command parameter
+	Param struct {
+		Name     string
+		Type     ArgType
+		Optional bool
+		Variadic bool
+		Choices  []string
+	}
+
+	// Arguments parsed from a command message by parameter name
+	Arguments struct {
+		values map[string][]interface{}
+	}
+
+	// Flag declares a command flag, i.e. --days 7 or -s. Flags with a Value take the next
+	// argument as their value.
+	Flag struct {
+		Name  string
+		Short string
+		Value string
+	}
+
+	// Token of a command message and its position in the raw arguments
+	Token struct {
+		Value  string
+		Pos    int
+		End    int
+		Quoted bool
+	}
+
+	// ArgError of a missing or invalid command argument
+	ArgError struct {
+		Param   Param
+		Message string
+	}
+)
+
+// snowflake matches a Discord ID, optionally inside a mention
+var snowflake = regexp.MustCompile(`^<?(?:@!?|@&|#)?(\d{15,21})>?$`)
+
+func (e ArgError) Error() string {
+	return e.Message
+}
+
+// String :
+// Returns the usage of a parameter, i.e. <member...> or [reason].
+func (p Param) String() string {
+	name := p.Name
+	if p.Type == ArgEnum {
+		name = strings.Join(p.Choices, "|")
+	}
+
+	if p.Variadic {
+		name += "..."
+	}
+
+	if p.Optional {
+		return "[" + name + "]"
+	}
+	return "<" + name + ">"
+}
+
+// String :
+// Returns the usage of a flag, i.e. [-d|--days <days>].
+func (f Flag) String() string {
+	name := "--" + f.Name
+	if len(f.Short) != 0 {
+		name = "-" + f.Short + "|" + name
+	}
+
+	if len(f.Value) != 0 {
+		name += " <" + f.Value + ">"
+	}
+	return "[" + name + "]"
+}
+
+// UsageString :
+// Returns the usage of a command from its parameters, or from its Usage if it has none,
+// followed by its flags. Groups without either list their subcommands.
+func (c Command) UsageString() string {
+	usage := c.Usage
+	if len(c.Params) != 0 {
+		usage = nil
+		for _, p := range c.Params {
+			usage = append(usage, p.String())
+		}
+	}
+
+	if len(usage) == 0 && len(c.Subcommands) != 0 {
+		var names []string
+		for _, sub := range c.Subcommands {
+			names = append(names, sub.Name)
+		}
+		usage = []string{"<" + strings.Join(names, "|") + ">"}
+	}
+
+	for _, f := range c.Flags {
+		usage = append(usage, f.String())
+	}
+	return strings.Join(usage, " ")
+}
+
+// quotes maps opening quotes to their closing quote
+var quotes = map[rune]rune{
+	'"':      '"',
+	'\'':     '\'',
+	'\u201c': '\u201d',
+}
+
+// Tokenize :
+// Splits a string into arguments by whitespace. Arguments starting with a quote run until the
+// matching closing quote. Outside of single quotes a backslash escapes the next character, so
+// a\ b is one argument.
+// Quotes inside of a word (i.e. don't) and unterminated quotes are kept as plain characters.
+func Tokenize(str string) []Token {
+	var tokens []Token
+	runes := []rune(str)
+
+	// Byte offset of each rune, plus the end of the string
+	offsets := make([]int, 0, len(runes)+1)
+	for i := range str {
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(str))
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		var b strings.Builder
+
+		// Quoted argument, closed by its quote followed by whitespace or the end
+		if closing, ok := quotes[runes[i]]; ok {
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if runes[j] == '\\' && runes[i] != '\'' && j+1 < len(runes) {
+					j++
+					b.WriteRune(runes[j])
+					continue
+				}
+
+				if runes[j] == closing && (j+1 == len(runes) || unicode.IsSpace(runes[j+1])) {
+					break
+				}
+
+				b.WriteRune(runes[j])
+			}
+
+			if j < len(runes) {
+				tokens = append(tokens, Token{Value: b.String(), Pos: offsets[start], End: offsets[j+1], Quoted: true})
+				i = j + 1
+				continue
+			}
+
+			b.Reset()
+		}
+
+		for ; i < len(runes) && !unicode.IsSpace(runes[i]); i++ {
+			if runes[i] == '\\' && i+1 < len(runes) {
+				i++
+			}
+			b.WriteRune(runes[i])
+		}
+
+		tokens = append(tokens, Token{Value: b.String(), Pos: offsets[start], End: offsets[i]})
+	}
+
+	return tokens
+}
+
+// ParseFlags :
+// Removes the declared flags of a command from its arguments. A bare -- stops flag parsing,
+// and quoted arguments are never flags.
+func ParseFlags(flags []Flag, tokens []Token) ([]Token, map[string]string, error) {
+	var args []Token
+	parsed := make(map[string]string)
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+
+		if t.Quoted || !isFlag(t.Value) {
+			args = append(args, t)
+			continue
+		}
+
+		if t.Value == "--" {
+			args = append(args, tokens[i+1:]...)
+			break
+		}
+
+		name, value, hasValue := strings.TrimLeft(t.Value, "-"), "", false
+		if v := strings.SplitN(name, "=", 2); len(v) == 2 {
+			name, value, hasValue = v[0], v[1], true
+		}
+
+		var flag *Flag
+		for j, f := range flags {
+			if (strings.HasPrefix(t.Value, "--") && strings.EqualFold(f.Name, name)) || (!strings.HasPrefix(t.Value, "--") && f.Short == name) {
+				flag = &flags[j]
+				break
+			}
+		}
+
+		if flag == nil {
+			return args, parsed, fmt.Errorf("Unknown flag `%s`", t.Value)
+		}
+
+		if len(flag.Value) != 0 && !hasValue {
+			if i+1 == len(tokens) {
+				return args, parsed, fmt.Errorf("Flag `--%s` needs a value", flag.Name)
+			}
+			i++
+			value = tokens[i].Value
+		}
+
+		parsed[flag.Name] = value
+	}
+
+	return args, parsed, nil
+}
+
+// isFlag :
+// Checks if an argument looks like a flag, i.e. --days, -d or --, but not a negative number.
+func isFlag(arg string) bool {
+	if arg == "--" {
+		return true
+	}
+
+	if strings.HasPrefix(arg, "--") {
+		return len(arg) > 2 && unicode.IsLetter([]rune(arg)[2])
+	}
+
+	return len(arg) > 1 && arg[0] == '-' && unicode.IsLetter([]rune(arg)[1])
+}
+
+// RawArgs :
+// Returns the arguments from index i onward as typed, keeping their spacing and quotes. A
+// single quoted argument is returned without its quotes.
+func (ctx Context) RawArgs(i int) string {
+	if i >= len(ctx.tokens) {
+		return ""
+	}
+
+	last := len(ctx.tokens) - 1
+	if i == last {
+		return ctx.tokens[i].Value
+	}
+
+	// Flags between arguments are dropped by joining the arguments instead
+	for j := i; j < last; j++ {
+		if len(strings.TrimSpace(ctx.raw[ctx.tokens[j].End:ctx.tokens[j+1].Pos])) != 0 {
+			return strings.Join(ctx.Args[i:], " ")
+		}
+	}
+
+	return ctx.raw[ctx.tokens[i].Pos:ctx.tokens[last].End]
+}
+
+// HasFlag :
+// Checks if a flag was given.
+func (ctx Context) HasFlag(name string) bool {
+	_, ok := ctx.Flags[name]
+	return ok
+}
+
+// ParseArguments :
+// Parses the arguments of a command against its parameters. Each parameter consumes as many
+// arguments as it can (one unless variadic), optional parameters are skipped when the next
+// argument does not fit, and a rest parameter takes the remainder of the message.
+func ParseArguments(ctx Context, params []Param) (Arguments, error) {
+	parsed := Arguments{values: make(map[string][]interface{})}
+	tokens := ctx.Args
+
+	i := 0
+	for _, p := range params {
+		if p.Type == ArgRest {
+			if i < len(tokens) {
+				parsed.values[p.Name] = []interface{}{ctx.RawArgs(i)}
+				i = len(tokens)
+			}
+		}
+
+		for i < len(tokens) && p.Type != ArgRest {
+			v, err := parseArgument(ctx, p, tokens[i])
+			if err != nil {
+				if len(parsed.values[p.Name]) == 0 && !p.Optional {
+					return parsed, err
+				}
+				break
+			}
+
+			parsed.values[p.Name] = append(parsed.values[p.Name], v)
+			i++
+
+			if !p.Variadic {
+				break
+			}
+		}
+
+		if len(parsed.values[p.Name]) == 0 && !p.Optional {
+			return parsed, ArgError{p, fmt.Sprintf("Missing argument `%s`", p)}
+		}
+	}
+
+	if i < len(tokens) {
+		return parsed, ArgError{Message: fmt.Sprintf("Unexpected argument `%s`", tokens[i])}
+	}
+
+	return parsed, nil
+}
+
+// parseArgument :
+// Converts a single argument into the type of a parameter.
+func parseArgument(ctx Context, p Param, arg string) (interface{}, error) {
+	invalid := func(what string) error {
+		return ArgError{p, fmt.Sprintf("`%s` is not a valid %s for `%s`", arg, what, p.Name)}
+	}
+
+	switch p.Type {
+	case ArgInt:
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, invalid("number")
+		}
+		return n, nil
+	case ArgDuration:
+		d, err := time.ParseDuration(arg)
+		if err != nil || d <= 0 {
+			return nil, invalid("duration")
+		}
+		return d, nil
+	case ArgEnum:
+		for _, v := range p.Choices {
+			if strings.EqualFold(v, arg) {
+				return v, nil
+			}
+		}
+		return nil, invalid("choice")
+	case ArgUser:
+		if u := resolveUser(ctx, arg); u != nil {
+			return u, nil
+		}
+		return nil, invalid("user")
+	case ArgMember:
+		if u := resolveUser(ctx, arg); u != nil && ctx.Guild != nil {
+			if m, err := FetchMember(ctx.Session, ctx.Guild.ID, u.ID); err == nil {
+				return m, nil
+			}
+		}
+		return nil, invalid("member")
+	case ArgRole:
+		if r := resolveRole(ctx, arg); r != nil {
+			return r, nil
+		}
+		return nil, invalid("role")
+	case ArgChannel:
+		if c := resolveChannel(ctx, arg); c != nil {
+			return c, nil
+		}
+		return nil, invalid("channel")
+	}
+
+	return arg, nil
+}
+
+// resolveUser :
+// Finds a user by mention, ID, or the Name#xxxx of a guild member. Mentions and guild members
+// are found without asking Discord, which is only asked for users outside of the guild.
+func resolveUser(ctx Context, arg string) *discordgo.User {
+	if match := snowflake.FindStringSubmatch(arg); match != nil && !strings.HasPrefix(arg, "<#") && !strings.HasPrefix(arg, "<@&") {
+		id := match[1]
+
+		if ctx.Event != nil {
+			for _, u := range ctx.Event.Mentions {
+				if u.ID == id {
+					return u
+				}
+			}
+		}
+
+		if ctx.Guild != nil {
+			if m, err := ctx.Session.State.Member(ctx.Guild.ID, id); err == nil {
+				return m.User
+			}
+		}
+
+		u, err := ctx.Session.User(id)
+		if err != nil {
+			return nil
+		}
+		return u
+	}
+
+	if ctx.Guild == nil || !strings.Contains(arg, "#") {
+		return nil
+	}
+
+	guild, err := ctx.Session.State.Guild(ctx.Guild.ID)
+	if err != nil {
+		return nil
+	}
+
+	for _, m := range guild.Members {
+		if strings.EqualFold(m.User.Username+"#"+m.User.Discriminator, arg) {
+			return m.User
+		}
+	}
+
+	return nil
+}
+
+// resolveRole :
+// Finds a guild role by mention, ID, or name regardless of case.
+func resolveRole(ctx Context, arg string) *discordgo.Role {
+	if ctx.Guild == nil {
+		return nil
+	}
+
+	guild, err := ctx.Session.State.Guild(ctx.Guild.ID)
+	if err != nil {
+		return nil
+	}
+
+	id := ""
+	if match := snowflake.FindStringSubmatch(arg); match != nil {
+		id = match[1]
+	}
+
+	for _, r := range guild.Roles {
+		if r.ID == id || strings.EqualFold(r.Name, arg) {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// resolveChannel :
+// Finds a guild channel by mention, ID, or name regardless of case.
+func resolveChannel(ctx Context, arg string) *discordgo.Channel {
+	if ctx.Guild == nil {
+		return nil
+	}
+
+	guild, err := ctx.Session.State.Guild(ctx.Guild.ID)
+	if err != nil {
+		return nil
+	}
+
+	id := ""
+	if match := snowflake.FindStringSubmatch(arg); match != nil {
+		id = match[1]
+	}
+
+	for _, c := range guild.Channels {
+		if c.ID == id || strings.EqualFold(c.Name, strings.TrimPrefix(arg, "#")) {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// Has :
+// Checks if an argument was given for a parameter.
+func (a Arguments) Has(name string) bool {
+	return len(a.values[name]) != 0
+}
+
+// String :
+// Returns a string, enum or rest argument, or an empty string.
+func (a Arguments) String(name string) string {
+	if v, ok := a.first(name).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// Int :
+// Returns a number argument, or 0.
+func (a Arguments) Int(name string) int {
+	if v, ok := a.first(name).(int); ok {
+		return v
+	}
+	return 0
+}
+
+// Duration :
+// Returns a duration argument, or 0.
+func (a Arguments) Duration(name string) time.Duration {
+	if v, ok := a.first(name).(time.Duration); ok {
+		return v
+	}
+	return 0
+}
+
+// User :
+// Returns the first user argument, or nil.
+func (a Arguments) User(name string) *discordgo.User {
+	if users := a.Users(name); len(users) != 0 {
+		return users[0]
+	}
+	return nil
+}
+
+// Users :
+// Returns the user arguments, including the users of member arguments.
+func (a Arguments) Users(name string) []*discordgo.User {
+	var arr []*discordgo.User
+	for _, v := range a.values[name] {
+		switch u := v.(type) {
+		case *discordgo.User:
+			arr = append(arr, u)
+		case *discordgo.Member:
+			arr = append(arr, u.User)
+		}
+	}
+	return arr
+}
+
+// Member :
+// Returns the first member argument, or nil.
+func (a Arguments) Member(name string) *discordgo.Member {
+	if members := a.Members(name); len(members) != 0 {
+		return members[0]
+	}
+	return nil
+}
+
+// Members :
+// Returns the member arguments.
+func (a Arguments) Members(name string) []*discordgo.Member {
+	var arr []*discordgo.Member
+	for _, v := range a.values[name] {
+		if m, ok := v.(*discordgo.Member); ok {
+			arr = append(arr, m)
+		}
+	}
+	return arr
+}
+
+// Role :
+// Returns the first role argument, or nil.
+func (a Arguments) Role(name string) *discordgo.Role {
+	if roles := a.Roles(name); len(roles) != 0 {
+		return roles[0]
+	}
+	return nil
+}
+
+// Roles :
+// Returns the role arguments.
+func (a Arguments) Roles(name string) []*discordgo.Role {
+	var arr []*discordgo.Role
+	for _, v := range a.values[name] {
+		if r, ok := v.(*discordgo.Role); ok {
+			arr = append(arr, r)
+		}
+	}
+	return arr
+}
+
+// Channel :
+// Returns the first channel argument, or nil.
+func (a Arguments) Channel(name string) *discordgo.Channel {
+	if channels := a.Channels(name); len(channels) != 0 {
+		return channels[0]
+	}
+	return nil
+}
+
+// Channels :
+// Returns the channel arguments.
+func (a Arguments) Channels(name string) []*discordgo.Channel {
+	var arr []*discordgo.Channel
+	for _, v := range a.values[name] {
+		if c, ok := v.(*discordgo.Channel); ok {
+			arr = append(arr, c)
+		}
+	}
+	return arr
+}
+
+// first :
+// Returns the first value of a parameter, or nil.
+func (a Arguments) first(name string) interface{} {
+	if len(a.values[name]) == 0 {
+		return nil
+	}
+	return a.values[name][0]
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func tokenValues(tokens []Token) []string {
+	var values []string
+	for _, t := range tokens {
+		values = append(values, t.Value)
+	}
+	return values
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"a b  c", []string{"a", "b", "c"}},
+		{" leading space", []string{"leading", "space"}},
+		{`"two words" three`, []string{"two words", "three"}},
+		{`'single quoted'`, []string{"single quoted"}},
+		{"“smart quotes”", []string{"smart quotes"}},
+		{`"escaped \" quote"`, []string{`escaped " quote`}},
+		{`'no \escapes'`, []string{`no \escapes`}},
+		{`a\ b c`, []string{"a b", "c"}},
+		{`a\"b`, []string{`a"b`}},
+		{`trailing\`, []string{`trailing\`}},
+		{"don't stop", []string{"don't", "stop"}},
+		{`"unterminated quote`, []string{`"unterminated`, "quote"}},
+		{`"a"b c"`, []string{`a"b c`}},
+	}
+
+	for _, tt := range tests {
+		if got := tokenValues(Tokenize(tt.in)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTokenizePositions(t *testing.T) {
+	in := `ban "two words"  x`
+	want := [][2]int{{0, 3}, {4, 15}, {17, 18}}
+
+	for i, tok := range Tokenize(in) {
+		if got := [2]int{tok.Pos, tok.End}; got != want[i] {
+			t.Errorf("Tokenize(%q)[%d] spans %v, want %v", in, i, got, want[i])
+		}
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	flags := []Flag{
+		{Name: "days", Short: "d", Value: "days"},
+		{Name: "silent", Short: "s"},
+	}
+
+	tests := []struct {
+		in      string
+		args    []string
+		flags   map[string]string
+		wantErr bool
+	}{
+		{"a b", []string{"a", "b"}, map[string]string{}, false},
+		{"a --days 7 b", []string{"a", "b"}, map[string]string{"days": "7"}, false},
+		{"--days=7 a", []string{"a"}, map[string]string{"days": "7"}, false},
+		{"-d 7 -s a", []string{"a"}, map[string]string{"days": "7", "silent": ""}, false},
+		{"--DAYS 7", nil, map[string]string{"days": "7"}, false},
+		{"a -- --days 7", []string{"a", "--days", "7"}, map[string]string{}, false},
+		{`"--days" 7`, []string{"--days", "7"}, map[string]string{}, false},
+		{"-5 a", []string{"-5", "a"}, map[string]string{}, false},
+		{"a --unknown", nil, nil, true},
+		{"a --days", nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		args, parsed, err := ParseFlags(flags, Tokenize(tt.in))
+
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseFlags(%q) did not fail", tt.in)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseFlags(%q) failed: %s", tt.in, err)
+			continue
+		}
+
+		if got := tokenValues(args); !reflect.DeepEqual(got, tt.args) {
+			t.Errorf("ParseFlags(%q) args = %q, want %q", tt.in, got, tt.args)
+		}
+
+		if !reflect.DeepEqual(parsed, tt.flags) {
+			t.Errorf("ParseFlags(%q) flags = %v, want %v", tt.in, parsed, tt.flags)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+/**
+ * attachments.go
+ * Chase Weaver
+ *
+ * This package handles the local attachment cache used to re-upload
+ * images from deleted messages.
+ */
+
+// Attachment cache configuration
+const (
+	AttachmentCacheDir         = "cache/attachments"
+	MaxCachedAttachmentSize    = 8 * 1024 * 1024
+	AttachmentCacheDefaultTime = 15 * time.Minute
+	AttachmentDownloadTimeout  = 30 * time.Second
+)
+
+// attachmentClient downloads attachments, giving up on stalled downloads
+var attachmentClient = &http.Client{Timeout: AttachmentDownloadTimeout}
+
+// IsImageAttachment :
+// Checks if an attachment is an image (Discord only sets dimensions for images).
+func IsImageAttachment(a *discordgo.MessageAttachment) bool {
+	return a.Width > 0 && a.Height > 0
+}
+
+// attachmentDir :
+// Returns the local cache directory of a message's attachments.
+func attachmentDir(guildID, messageID string) string {
+	return filepath.Join(AttachmentCacheDir, guildID, messageID)
+}
+
+// CacheAttachments :
+// Downloads the image attachments of a message to the local attachment cache.
+func CacheAttachments(guildID string, m *discordgo.Message) {
+
+	for _, a := range m.Attachments {
+
+		if !IsImageAttachment(a) || a.Size > MaxCachedAttachmentSize {
+			continue
+		}
+
+		err := os.MkdirAll(attachmentDir(guildID, m.ID), 0755)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		resp, err := attachmentClient.Get(a.URL)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			log.Println("attachment download failed:", resp.Status)
+			continue
+		}
+
+		// Strip any path from the filename before writing it to disk, prefixed with the attachment
+		// ID so attachments with the same name do not overwrite each other
+		path := filepath.Join(attachmentDir(guildID, m.ID), a.ID+"_"+filepath.Base(a.Filename))
+		f, err := os.Create(path)
+		if err != nil {
+			resp.Body.Close()
+			log.Println(err)
+			continue
+		}
+
+		// Read one byte past the cap to drop attachments larger than they claimed to be
+		n, err := io.Copy(f, io.LimitReader(resp.Body, MaxCachedAttachmentSize+1))
+		resp.Body.Close()
+		f.Close()
+
+		if err != nil || n > MaxCachedAttachmentSize {
+			if err != nil {
+				log.Println(err)
+			}
+			os.Remove(path)
+		}
+	}
+}
+
+// FetchCachedAttachments :
+// Returns the cached attachments of a message as files ready to be uploaded.
+func FetchCachedAttachments(guildID, messageID string) []*discordgo.File {
+
+	var files []*discordgo.File
+	dir := attachmentDir(guildID, messageID)
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return files
+	}
+
+	for _, v := range entries {
+		data, err := ioutil.ReadFile(filepath.Join(dir, v.Name()))
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		files = append(files, &discordgo.File{
+			Name:   v.Name(),
+			Reader: bytes.NewReader(data),
+		})
+	}
+
+	return files
+}
+
+// RemoveCachedAttachments :
+// Removes the cached attachments of a message.
+func RemoveCachedAttachments(guildID, messageID string) {
+	err := os.RemoveAll(attachmentDir(guildID, messageID))
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// PurgeAttachmentCache :
+// Removes cached attachments older than the guild's message log retention, or the
+// default cache time if the persistent message log is disabled.
+func PurgeAttachmentCache() {
+
+	guilds, err := ioutil.ReadDir(AttachmentCacheDir)
+	if err != nil {
+		return
+	}
+
+	for _, guild := range guilds {
+
+		maxAge := AttachmentCacheDefaultTime
+		if g, err := UnpackGuildStruct(guild.Name()); err == nil && g.MessageLog.Enabled {
+			maxAge = g.MessageLog.retention()
+		}
+
+		messages, err := ioutil.ReadDir(filepath.Join(AttachmentCacheDir, guild.Name()))
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		for _, m := range messages {
+			if time.Since(m.ModTime()) > maxAge {
+				RemoveCachedAttachments(guild.Name(), m.Name())
+			}
+		}
+	}
+}
@@ -0,0 +1,631 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+/**
+ * audit.go
+ * Chase Weaver
+ *
+ * This package bundles event handlers for logging role, channel, ban, emoji,
+ * and guild changes to the guild log channels.
+ */
+
+// Emojis per guild, used to find emoji changes on updates
+var (
+	emojis   = make(map[string][]*discordgo.Emoji)
+	emojisMu sync.Mutex
+)
+
+// Previous fields of roles, channels, and guilds by ID, used to find changes on updates. Discord
+// does not send the previous state and the session state is updated before handlers run.
+var (
+	snapshots   = make(map[string][][2]string)
+	snapshotsMu sync.Mutex
+)
+
+// Time to look back for matching audit log entries
+const auditLogWindow = 10 * time.Second
+
+// FetchAuditLogEntry :
+// Returns the most recent audit log entry of an action type for a target, and the user responsible
+// for it, if it was made within the audit log window.
+func FetchAuditLogEntry(s *discordgo.Session, guildID, targetID string, action int) (*discordgo.AuditLogEntry, *discordgo.User) {
+
+	audit, err := s.GuildAuditLog(guildID, "", "", action, 10)
+	if err != nil {
+		log.Println(err)
+		return nil, nil
+	}
+
+	for _, v := range audit.AuditLogEntries {
+		if targetID != "" && v.TargetID != targetID {
+			continue
+		}
+
+		t, err := CreationTime(v.ID)
+		if err != nil || time.Since(t) > auditLogWindow {
+			continue
+		}
+
+		// Only log an audit log entry once
+		if _, found := c.Get("audit:" + v.ID); found {
+			continue
+		}
+		c.Set("audit:"+v.ID, true, 2*auditLogWindow)
+
+		for _, u := range audit.Users {
+			if u.ID == v.UserID {
+				return v, u
+			}
+		}
+
+		return v, nil
+	}
+
+	return nil, nil
+}
+
+// SendAuditLog :
+// Sends an embed to the channel an audit log event type is logged to.
+func SendAuditLog(s *discordgo.Session, guildID, event string, e *Embed) {
+
+	// Fetch Guild information from redis database
+	g, err := UnpackGuildStruct(guildID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	SendGuildLogEmbed(s, g, event, e.SetTimestamp(time.Now().Format(time.RFC3339)).Truncate().MessageEmbed)
+}
+
+// AuditLogEnabled :
+// Checks if the guild has a log channel set for an audit log event type.
+func AuditLogEnabled(guildID, event string) bool {
+	g, err := UnpackGuildStruct(guildID)
+	return err == nil && g.LogChannelFor(event) != nil
+}
+
+// AddAuditLogEntry :
+// Adds the responsible moderator, changes, and reason of an audit log entry to an embed.
+func (e *Embed) AddAuditLogEntry(entry *discordgo.AuditLogEntry, user *discordgo.User) *Embed {
+
+	if user != nil {
+		e.AddField("Moderator", fmt.Sprintf("%s#%s / %s", user.Username, user.Discriminator, user.ID))
+	}
+
+	if entry == nil {
+		return e
+	}
+
+	if changes := FormatAuditLogChanges(entry.Changes); changes != "" {
+		e.AddLongField("Changes", changes)
+	}
+
+	if entry.Reason != "" {
+		e.AddField("Reason", entry.Reason)
+	}
+
+	return e
+}
+
+// AddAuditLogModerator :
+// Adds the responsible moderator and reason of an audit log entry to an embed, if it was found.
+func (e *Embed) AddAuditLogModerator(entry *discordgo.AuditLogEntry, user *discordgo.User) *Embed {
+
+	if user != nil {
+		e.AddField("Moderator", fmt.Sprintf("%s#%s / %s", user.Username, user.Discriminator, user.ID))
+	}
+
+	if entry != nil && entry.Reason != "" {
+		e.AddField("Reason", entry.Reason)
+	}
+
+	return e
+}
+
+// FormatAuditLogChanges :
+// Returns a string of audit log changes, i.e. "Name: old ➜ new".
+func FormatAuditLogChanges(changes []*discordgo.AuditLogChange) string {
+	var str []string
+
+	for _, v := range changes {
+		key := strings.Title(strings.Replace(strings.TrimPrefix(v.Key, "$"), "_", " ", -1))
+
+		switch {
+		case v.OldValue == nil:
+			str = append(str, fmt.Sprintf("**%s**: %s", key, formatAuditLogValue(v.NewValue)))
+		case v.NewValue == nil:
+			str = append(str, fmt.Sprintf("**%s**: ~~%s~~", key, formatAuditLogValue(v.OldValue)))
+		default:
+			str = append(str, fmt.Sprintf("**%s**: %s ➜ %s", key, formatAuditLogValue(v.OldValue), formatAuditLogValue(v.NewValue)))
+		}
+	}
+
+	return strings.Join(str, "\n")
+}
+
+// formatAuditLogValue :
+// Returns a readable audit log change value, listing the names of partial roles.
+func formatAuditLogValue(v interface{}) string {
+
+	arr, ok := v.([]interface{})
+	if !ok {
+		return fmt.Sprintf("`%v`", v)
+	}
+
+	var str []string
+	for _, k := range arr {
+		if m, ok := k.(map[string]interface{}); ok && m["name"] != nil {
+			str = append(str, fmt.Sprintf("`%v`", m["name"]))
+		} else {
+			str = append(str, fmt.Sprintf("`%v`", k))
+		}
+	}
+
+	return strings.Join(str, ", ")
+}
+
+// auditLogChangeValue :
+// Returns the old (or new, if missing) value of an audit log change by key.
+func auditLogChangeValue(entry *discordgo.AuditLogEntry, key string) string {
+	if entry == nil {
+		return ""
+	}
+
+	for _, v := range entry.Changes {
+		if v.Key != key {
+			continue
+		}
+
+		if v.OldValue != nil {
+			return fmt.Sprintf("%v", v.OldValue)
+		}
+
+		return fmt.Sprintf("%v", v.NewValue)
+	}
+
+	return ""
+}
+
+// FormatChanges :
+// Returns a string of the fields that differ between two snapshots, i.e. "Name: old ➜ new".
+func FormatChanges(old, cur [][2]string) string {
+	prev := make(map[string]string)
+	for _, v := range old {
+		prev[v[0]] = v[1]
+	}
+
+	var str []string
+	for _, v := range cur {
+		o, ok := prev[v[0]]
+		if !ok || o == v[1] {
+			continue
+		}
+
+		switch {
+		case o == "":
+			str = append(str, fmt.Sprintf("**%s**: `%s`", v[0], v[1]))
+		case v[1] == "":
+			str = append(str, fmt.Sprintf("**%s**: ~~`%s`~~", v[0], o))
+		default:
+			str = append(str, fmt.Sprintf("**%s**: `%s` ➜ `%s`", v[0], o, v[1]))
+		}
+	}
+
+	return strings.Join(str, "\n")
+}
+
+// Snapshot :
+// Stores the fields of a role, channel, or guild and returns the previous ones, if any.
+func Snapshot(id string, fields [][2]string) ([][2]string, bool) {
+	snapshotsMu.Lock()
+	defer snapshotsMu.Unlock()
+
+	old, ok := snapshots[id]
+	if fields == nil {
+		delete(snapshots, id)
+	} else {
+		snapshots[id] = fields
+	}
+
+	return old, ok
+}
+
+// SnapshotGuild :
+// Stores the fields of a guild, its roles and its channels, and its emojis.
+func SnapshotGuild(g *discordgo.Guild) {
+	Snapshot(g.ID, guildFields(g))
+
+	for _, v := range g.Roles {
+		Snapshot(v.ID, roleFields(v))
+	}
+
+	for _, v := range g.Channels {
+		Snapshot(v.ID, channelFields(v))
+	}
+
+	SnapshotEmojis(g.ID, g.Emojis)
+}
+
+// roleFields :
+// Returns the logged fields of a role. The position is left out as reordering updates every role.
+func roleFields(r *discordgo.Role) [][2]string {
+	return [][2]string{
+		{"Name", r.Name},
+		{"Color", fmt.Sprintf("#%06x", r.Color)},
+		{"Hoist", fmt.Sprint(r.Hoist)},
+		{"Mentionable", fmt.Sprint(r.Mentionable)},
+		{"Permissions", fmt.Sprint(r.Permissions)},
+	}
+}
+
+// channelFields :
+// Returns the logged fields of a channel. The position is left out as reordering updates every
+// channel.
+func channelFields(c *discordgo.Channel) [][2]string {
+	var overwrites []string
+	for _, v := range c.PermissionOverwrites {
+		overwrites = append(overwrites, fmt.Sprintf("%s %s +%d -%d", v.Type, v.ID, v.Allow, v.Deny))
+	}
+	sort.Strings(overwrites)
+
+	return [][2]string{
+		{"Name", c.Name},
+		{"Topic", c.Topic},
+		{"NSFW", fmt.Sprint(c.NSFW)},
+		{"Category", c.ParentID},
+		{"Bitrate", fmt.Sprint(c.Bitrate)},
+		{"User Limit", fmt.Sprint(c.UserLimit)},
+		{"Permission Overwrites", strings.Join(overwrites, ", ")},
+	}
+}
+
+// guildFields :
+// Returns the logged fields of a guild.
+func guildFields(g *discordgo.Guild) [][2]string {
+	return [][2]string{
+		{"Name", g.Name},
+		{"Icon", g.Icon},
+		{"Splash", g.Splash},
+		{"Region", g.Region},
+		{"Owner", g.OwnerID},
+		{"AFK Channel", g.AfkChannelID},
+		{"AFK Timeout", fmt.Sprint(g.AfkTimeout)},
+		{"System Channel", g.SystemChannelID},
+		{"Verification Level", fmt.Sprint(g.VerificationLevel)},
+		{"Explicit Content Filter", fmt.Sprint(g.ExplicitContentFilter)},
+		{"Default Notifications", fmt.Sprint(g.DefaultMessageNotifications)},
+	}
+}
+
+// SnapshotEmojis :
+// Stores a guild's emojis for comparing emoji updates.
+func SnapshotEmojis(guildID string, e []*discordgo.Emoji) {
+	emojisMu.Lock()
+	defer emojisMu.Unlock()
+
+	emojis[guildID] = e
+}
+
+// GuildRoleCreate :
+// Logs created roles to the guild log channel.
+func GuildRoleCreate(s *discordgo.Session, m *discordgo.GuildRoleCreate) {
+
+	Snapshot(m.Role.ID, roleFields(m.Role))
+
+	if !AuditLogEnabled(m.GuildID, LogEventRole) {
+		return
+	}
+
+	entry, user := FetchAuditLogEntry(s, m.GuildID, m.Role.ID, discordgo.AuditLogActionRoleCreate)
+
+	SendAuditLog(s, m.GuildID, LogEventRole,
+		NewEmbed().
+			SetTitle("Role Created").
+			SetColor(createColor).
+			AddField("Role", fmt.Sprintf("<@&%s> / %s", m.Role.ID, m.Role.ID)).
+			AddAuditLogEntry(entry, user))
+}
+
+// GuildRoleUpdate :
+// Logs role changes to the guild log channel, ignoring position changes.
+func GuildRoleUpdate(s *discordgo.Session, m *discordgo.GuildRoleUpdate) {
+
+	fields := roleFields(m.Role)
+	old, ok := Snapshot(m.Role.ID, fields)
+
+	// Nothing to compare against until the role has been seen, and reordering changes nothing logged
+	changes := FormatChanges(old, fields)
+	if !ok || changes == "" || !AuditLogEnabled(m.GuildID, LogEventRole) {
+		return
+	}
+
+	entry, user := FetchAuditLogEntry(s, m.GuildID, m.Role.ID, discordgo.AuditLogActionRoleUpdate)
+
+	SendAuditLog(s, m.GuildID, LogEventRole,
+		NewEmbed().
+			SetTitle("Role Updated").
+			SetColor(updateColor).
+			AddField("Role", fmt.Sprintf("<@&%s> / %s", m.Role.ID, m.Role.ID)).
+			AddLongField("Changes", changes).
+			AddAuditLogModerator(entry, user))
+}
+
+// GuildRoleDelete :
+// Logs deleted roles to the guild log channel.
+func GuildRoleDelete(s *discordgo.Session, m *discordgo.GuildRoleDelete) {
+
+	old, _ := Snapshot(m.RoleID, nil)
+
+	if !AuditLogEnabled(m.GuildID, LogEventRole) {
+		return
+	}
+
+	entry, user := FetchAuditLogEntry(s, m.GuildID, m.RoleID, discordgo.AuditLogActionRoleDelete)
+
+	name := auditLogChangeValue(entry, "name")
+	if name == "" && len(old) != 0 {
+		name = old[0][1]
+	}
+
+	if name == "" {
+		name = "Unknown"
+	}
+
+	SendAuditLog(s, m.GuildID, LogEventRole,
+		NewEmbed().
+			SetTitle("Role Deleted").
+			SetColor(removeColor).
+			AddField("Role", fmt.Sprintf("%s / %s", name, m.RoleID)).
+			AddAuditLogEntry(entry, user))
+}
+
+// ChannelCreate :
+// Logs created channels to the guild log channel.
+func ChannelCreate(s *discordgo.Session, m *discordgo.ChannelCreate) {
+
+	if m.GuildID != "" {
+		Snapshot(m.ID, channelFields(m.Channel))
+	}
+
+	// Ignore DM channels and guilds without an audit log
+	if m.GuildID == "" || !AuditLogEnabled(m.GuildID, LogEventChannel) {
+		return
+	}
+
+	entry, user := FetchAuditLogEntry(s, m.GuildID, m.ID, discordgo.AuditLogActionChannelCreate)
+
+	SendAuditLog(s, m.GuildID, LogEventChannel,
+		NewEmbed().
+			SetTitle("Channel Created").
+			SetColor(createColor).
+			AddField("Channel", fmt.Sprintf("<#%s> / %s", m.ID, m.ID)).
+			AddAuditLogEntry(entry, user))
+}
+
+// ChannelUpdate :
+// Logs channel changes to the guild log channel, ignoring position changes.
+func ChannelUpdate(s *discordgo.Session, m *discordgo.ChannelUpdate) {
+
+	// Ignore DM channels
+	if m.GuildID == "" {
+		return
+	}
+
+	fields := channelFields(m.Channel)
+	old, ok := Snapshot(m.ID, fields)
+
+	// Nothing to compare against until the channel has been seen, and reordering changes nothing logged
+	changes := FormatChanges(old, fields)
+	if !ok || changes == "" || !AuditLogEnabled(m.GuildID, LogEventChannel) {
+		return
+	}
+
+	entry, user := FetchAuditLogEntry(s, m.GuildID, m.ID, discordgo.AuditLogActionChannelUpdate)
+
+	SendAuditLog(s, m.GuildID, LogEventChannel,
+		NewEmbed().
+			SetTitle("Channel Updated").
+			SetColor(updateColor).
+			AddField("Channel", fmt.Sprintf("<#%s> / %s", m.ID, m.ID)).
+			AddLongField("Changes", changes).
+			AddAuditLogModerator(entry, user))
+}
+
+// ChannelDelete :
+// Logs deleted channels to the guild log channel.
+func ChannelDelete(s *discordgo.Session, m *discordgo.ChannelDelete) {
+
+	Snapshot(m.ID, nil)
+
+	// Ignore DM channels and guilds without an audit log
+	if m.GuildID == "" || !AuditLogEnabled(m.GuildID, LogEventChannel) {
+		return
+	}
+
+	entry, user := FetchAuditLogEntry(s, m.GuildID, m.ID, discordgo.AuditLogActionChannelDelete)
+
+	SendAuditLog(s, m.GuildID, LogEventChannel,
+		NewEmbed().
+			SetTitle("Channel Deleted").
+			SetColor(removeColor).
+			AddField("Channel", fmt.Sprintf("#%s / %s", m.Name, m.ID)).
+			AddAuditLogEntry(entry, user))
+}
+
+// GuildBanAdd :
+// Logs bans made outside of the bot to the guild log channel.
+func GuildBanAdd(s *discordgo.Session, m *discordgo.GuildBanAdd) {
+
+	if !AuditLogEnabled(m.GuildID, LogEventBan) {
+		return
+	}
+
+	entry, user := FetchAuditLogEntry(s, m.GuildID, m.User.ID, discordgo.AuditLogActionMemberBanAdd)
+
+	// Bans made by the bot are already in the moderation logs
+	if user != nil && user.ID == s.State.User.ID {
+		return
+	}
+
+	SendAuditLog(s, m.GuildID, LogEventBan,
+		NewEmbed().
+			SetTitle("Member Banned").
+			SetColor(banColor).
+			SetAuthor(fmt.Sprintf("%s#%s / %s", m.User.Username, m.User.Discriminator, m.User.ID), m.User.AvatarURL("256"), m.User.AvatarURL("2048")).
+			AddAuditLogEntry(entry, user))
+}
+
+// GuildBanRemove :
+// Logs unbans made outside of the bot to the guild log channel.
+func GuildBanRemove(s *discordgo.Session, m *discordgo.GuildBanRemove) {
+
+	if !AuditLogEnabled(m.GuildID, LogEventBan) {
+		return
+	}
+
+	entry, user := FetchAuditLogEntry(s, m.GuildID, m.User.ID, discordgo.AuditLogActionMemberBanRemove)
+
+	if user != nil && user.ID == s.State.User.ID {
+		return
+	}
+
+	SendAuditLog(s, m.GuildID, LogEventBan,
+		NewEmbed().
+			SetTitle("Member Unbanned").
+			SetColor(unmuteColor).
+			SetAuthor(fmt.Sprintf("%s#%s / %s", m.User.Username, m.User.Discriminator, m.User.ID), m.User.AvatarURL("256"), m.User.AvatarURL("2048")).
+			AddAuditLogEntry(entry, user))
+}
+
+// GuildEmojisUpdate :
+// Logs added, renamed, and removed emojis to the guild log channel.
+func GuildEmojisUpdate(s *discordgo.Session, m *discordgo.GuildEmojisUpdate) {
+
+	emojisMu.Lock()
+	old, ok := emojis[m.GuildID]
+	emojis[m.GuildID] = m.Emojis
+	emojisMu.Unlock()
+
+	// Nothing to compare against until the guild has been seen
+	if !ok || !AuditLogEnabled(m.GuildID, LogEventEmoji) {
+		return
+	}
+
+	prev := make(map[string]*discordgo.Emoji)
+	for _, v := range old {
+		prev[v.ID] = v
+	}
+
+	for _, v := range m.Emojis {
+		o, found := prev[v.ID]
+		delete(prev, v.ID)
+
+		switch {
+		case !found:
+			entry, user := FetchAuditLogEntry(s, m.GuildID, v.ID, discordgo.AuditLogActionEmojiCreate)
+			SendAuditLog(s, m.GuildID, LogEventEmoji,
+				NewEmbed().
+					SetTitle("Emoji Added").
+					SetColor(createColor).
+					SetThumbnail(discordgo.EndpointEmoji(v.ID)).
+					AddField("Emoji", fmt.Sprintf("%s `:%s:` / %s", v.MessageFormat(), v.Name, v.ID)).
+					AddAuditLogEntry(entry, user))
+		case o.Name != v.Name:
+			entry, user := FetchAuditLogEntry(s, m.GuildID, v.ID, discordgo.AuditLogActionEmojiUpdate)
+			SendAuditLog(s, m.GuildID, LogEventEmoji,
+				NewEmbed().
+					SetTitle("Emoji Renamed").
+					SetColor(updateColor).
+					SetThumbnail(discordgo.EndpointEmoji(v.ID)).
+					AddField("Emoji", fmt.Sprintf("%s `:%s:` ➜ `:%s:` / %s", v.MessageFormat(), o.Name, v.Name, v.ID)).
+					AddAuditLogEntry(entry, user))
+		}
+	}
+
+	for _, v := range prev {
+		entry, user := FetchAuditLogEntry(s, m.GuildID, v.ID, discordgo.AuditLogActionEmojiDelete)
+		SendAuditLog(s, m.GuildID, LogEventEmoji,
+			NewEmbed().
+				SetTitle("Emoji Removed").
+				SetColor(removeColor).
+				SetThumbnail(discordgo.EndpointEmoji(v.ID)).
+				AddField("Emoji", fmt.Sprintf("`:%s:` / %s", v.Name, v.ID)).
+				AddAuditLogEntry(entry, user))
+	}
+}
+
+// GuildUpdate :
+// Logs guild setting changes to the guild log channel.
+func GuildUpdate(s *discordgo.Session, m *discordgo.GuildUpdate) {
+
+	fields := guildFields(m.Guild)
+	old, ok := Snapshot(m.ID, fields)
+
+	// Nothing to compare against until the guild has been seen
+	changes := FormatChanges(old, fields)
+	if !ok || changes == "" || !AuditLogEnabled(m.ID, LogEventGuild) {
+		return
+	}
+
+	entry, user := FetchAuditLogEntry(s, m.ID, "", discordgo.AuditLogActionGuildUpdate)
+
+	SendAuditLog(s, m.ID, LogEventGuild,
+		NewEmbed().
+			SetTitle("Guild Updated").
+			SetColor(updateColor).
+			SetThumbnail(discordgo.EndpointGuildIcon(m.ID, m.Icon)).
+			AddLongField("Changes", changes).
+			AddAuditLogModerator(entry, user))
+}
+
+// LogMemberRoleUpdate :
+// Logs roles added to or removed from a member to the guild log channel, compared to the roles
+// they had before the update.
+func LogMemberRoleUpdate(s *discordgo.Session, m *discordgo.GuildMemberUpdate, old []string) {
+
+	var added, removed []string
+	for _, v := range m.Roles {
+		if !Contains(old, v) {
+			added = append(added, fmt.Sprintf("<@&%s>", v))
+		}
+	}
+
+	for _, v := range old {
+		if !Contains(m.Roles, v) {
+			removed = append(removed, fmt.Sprintf("<@&%s>", v))
+		}
+	}
+
+	// Nickname and other member updates leave the roles as they were
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+
+	entry, user := FetchAuditLogEntry(s, m.GuildID, m.User.ID, discordgo.AuditLogActionMemberRoleUpdate)
+
+	embed := NewEmbed().
+		SetTitle("Member Roles Updated").
+		SetColor(updateColor).
+		SetAuthor(fmt.Sprintf("%s#%s / %s", m.User.Username, m.User.Discriminator, m.User.ID), m.User.AvatarURL("256"), m.User.AvatarURL("2048"))
+
+	if len(added) != 0 {
+		embed.AddLongField("Added", strings.Join(added, ", "))
+	}
+
+	if len(removed) != 0 {
+		embed.AddLongField("Removed", strings.Join(removed, ", "))
+	}
+
+	SendAuditLog(s, m.GuildID, LogEventMemberRole, embed.AddAuditLogModerator(entry, user))
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+/**
+ * automod.go
+ * Chase Weaver
+ *
+ * This package handles exemptions for automated moderation actions.
+ */
+
+func init() {
+	exemptions := func(add bool) []Command {
+		verb := "Removes"
+		roleFunc, permFunc := ExemptRemoveRole, ExemptRemovePermission
+		if add {
+			verb = "Adds"
+			roleFunc, permFunc = ExemptAddRole, ExemptAddPermission
+		}
+
+		return []Command{
+			{
+				Name:        "role",
+				Func:        roleFunc,
+				Aliases:     []string{"roles"},
+				Params:      []Param{{Name: "role", Type: ArgRole, Variadic: true}},
+				Description: verb + " roles exempt from automated moderation.",
+			},
+			{
+				Name:        "permission",
+				Func:        permFunc,
+				Aliases:     []string{"permissions"},
+				Params:      []Param{{Name: "permission", Type: ArgRest}},
+				Description: verb + " a permission exempt from automated moderation.",
+			},
+		}
+	}
+
+	RegisterNewCommand(Command{
+		Name:            "exempt",
+		Func:            ExemptList,
+		Enabled:         true,
+		NSFWOnly:        false,
+		IgnoreSelf:      true,
+		IgnoreBots:      true,
+		Cooldown:        0,
+		RunIn:           []string{"Text"},
+		Aliases:         []string{"exemptions"},
+		UserPermissions: []string{"Bot Owner", "Manage Server"},
+		Usage:           []string{},
+		Subcommands: []Command{
+			{
+				Name:        "list",
+				Func:        ExemptList,
+				Description: "Lists the automated moderation exemptions.",
+			},
+			{
+				Name:        "add",
+				Subcommands: exemptions(true),
+				Description: "Exempts roles or a permission from automated moderation.",
+			},
+			{
+				Name:        "remove",
+				Subcommands: exemptions(false),
+				Description: "Removes automated moderation exemptions.",
+			},
+		},
+		Description: "Manages roles and permissions exempt from automated moderation.",
+	})
+}
+
+// IsAutomodExempt :
+// Checks if a member is exempt from automated moderation by role or permission. The bot itself
+// is always exempt.
+func IsAutomodExempt(s *discordgo.Session, g Guild, user *discordgo.User) bool {
+
+	if user == nil || g.Guild == nil {
+		return false
+	}
+
+	// Never act on the bot
+	if user.ID == s.State.User.ID {
+		return true
+	}
+
+	guild, err := s.State.Guild(g.Guild.ID)
+	if err != nil {
+		if guild, err = s.Guild(g.Guild.ID); err != nil {
+			log.Println(err)
+			return false
+		}
+	}
+
+	mem, err := s.State.Member(guild.ID, user.ID)
+	if err != nil {
+		if mem, err = s.GuildMember(guild.ID, user.ID); err != nil {
+			return false
+		}
+	}
+
+	// Exempt roles
+	for _, v := range g.AutomodExemptions.Roles {
+		if Contains(mem.Roles, v.ID) {
+			return true
+		}
+	}
+
+	// Exempt permissions, reusing the command permission checks
+	ctx := memberContext(s, guild, user)
+	for _, v := range g.AutomodExemptions.Permissions {
+		if MemberHasPermission(ctx, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// memberContext :
+// Builds a minimal Context for a guild member outside of a command, used for permission checks.
+func memberContext(s *discordgo.Session, guild *discordgo.Guild, user *discordgo.User) Context {
+	return Context{
+		Session: s,
+		Guild:   guild,
+		Event: &discordgo.MessageCreate{
+			Message: &discordgo.Message{
+				Author:  user,
+				GuildID: guild.ID,
+			},
+		},
+	}
+}
+
+// ExemptList :
+// Lists the automated moderation exemptions.
+func ExemptList(ctx Context) {
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, FormatString(FormatAutomodExemptions(ctx.Settings), "asciidoc"))
+}
+
+// ExemptAddRole :
+// Exempts roles from automated moderation.
+// [@Role(s)]
+func ExemptAddRole(ctx Context) {
+	setExemptRoles(ctx, true)
+}
+
+// ExemptRemoveRole :
+// Removes roles exempt from automated moderation.
+// [@Role(s)]
+func ExemptRemoveRole(ctx Context) {
+	setExemptRoles(ctx, false)
+}
+
+// ExemptAddPermission :
+// Exempts members with a permission from automated moderation.
+// [Permission Name]
+func ExemptAddPermission(ctx Context) {
+	setExemptPermission(ctx, true)
+}
+
+// ExemptRemovePermission :
+// Removes a permission exempt from automated moderation.
+// [Permission Name]
+func ExemptRemovePermission(ctx Context) {
+	setExemptPermission(ctx, false)
+}
+
+// setExemptRoles :
+// Adds or removes the roles in the arguments from the exempt roles.
+func setExemptRoles(ctx Context, add bool) {
+	updateAutomodExemptions(ctx, func(ex *AutomodExemptions) {
+		for _, r := range ctx.Arguments.Roles("role") {
+			ex.Roles = removeRole(ex.Roles, r.ID)
+			if add {
+				ex.Roles = append(ex.Roles, r)
+			}
+		}
+	})
+}
+
+// setExemptPermission :
+// Adds or removes the permission in the arguments from the exempt permissions.
+func setExemptPermission(ctx Context, add bool) {
+	val := ctx.Arguments.String("permission")
+
+	perm := ""
+	for k := range permissions {
+		if strings.EqualFold(k, val) {
+			perm = k
+		}
+	}
+
+	if perm == "" {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | `%s` is not a valid permission!", val))
+		return
+	}
+
+	updateAutomodExemptions(ctx, func(ex *AutomodExemptions) {
+		var tmp []string
+		for _, v := range ex.Permissions {
+			if v != perm {
+				tmp = append(tmp, v)
+			}
+		}
+
+		if add {
+			tmp = append(tmp, perm)
+		}
+
+		ex.Permissions = tmp
+	})
+}
+
+// updateAutomodExemptions :
+// Changes the guild's automated moderation exemptions and saves them.
+func updateAutomodExemptions(ctx Context, update func(ex *AutomodExemptions)) {
+
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	update(&g.AutomodExemptions)
+
+	err = PackGuildStruct(ctx.Guild.ID, g)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, "✅ | Automod exemptions updated!")
+}
+
+// FormatAutomodExemptions :
+// Returns a string of automated moderation exemptions.
+func FormatAutomodExemptions(g Guild) string {
+
+	var roles []string
+	for _, v := range g.AutomodExemptions.Roles {
+		roles = append(roles, v.Name)
+	}
+
+	return fmt.Sprintf(
+		"== Automod Exemptions ==\n\n"+
+			"Roles       ::   %s\n"+
+			"Permissions ::   %s",
+		strings.Join(roles, ", "), strings.Join(g.AutomodExemptions.Permissions, ", "))
+}
+
+// removeRole :
+// Returns the roles without the given role ID.
+func removeRole(roles []*discordgo.Role, ID string) []*discordgo.Role {
+	var tmp []*discordgo.Role
+	for _, v := range roles {
+		if v.ID != ID {
+			tmp = append(tmp, v)
+		}
+	}
+	return tmp
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"log"
-	"strings"
 	"time"
 
 	"github.com/Knetic/govaluate"
@@ -28,7 +27,6 @@ func init() {
 		RunIn:           []string{"Text", "DM"},
 		Aliases:         []string{},
 		UserPermissions: []string{},
-		ArgsDelim:       "",
 		Usage:           []string{},
 		Description:     "Pong! Responds with the heartbeat.",
 	})
@@ -44,7 +42,6 @@ func init() {
 		RunIn:           []string{"DM", "Text"},
 		Aliases:         []string{},
 		UserPermissions: []string{"Bot Owner"},
-		ArgsDelim:       " ",
 		Usage:           []string{},
 		Description:     "CAUTION! Flushes the database and reinitializes guild settings!",
 	})
@@ -60,7 +57,6 @@ func init() {
 		RunIn:           []string{"DM", "Text"},
 		Aliases:         []string{"e"},
 		UserPermissions: []string{"Bot Owner"},
-		ArgsDelim:       " ",
 		Usage:           []string{},
 		Description:     "Bot-owner evaluation function.",
 	})
@@ -76,7 +72,6 @@ func init() {
 		RunIn:           []string{"DM", "Text"},
 		Aliases:         []string{},
 		UserPermissions: []string{"Bot Owner"},
-		ArgsDelim:       " ",
 		Usage:           []string{},
 		Description:     "Bot-owner testing function.",
 	})
@@ -116,7 +111,7 @@ func Eval(ctx Context) {
 		return
 	}
 
-	expression, err := govaluate.NewEvaluableExpression(strings.Join(ctx.Args, ctx.Command.ArgsDelim))
+	expression, err := govaluate.NewEvaluableExpression(ctx.RawArgs(0))
 
 	if err != nil {
 		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "**ERROR**"+FormatString(err.Error(), "ascidoc"))
@@ -17,40 +17,123 @@ type (
 
 	// Guild configuration information per guild
 	Guild struct {
-		Guild                 *discordgo.Guild
-		GuildPrefix           string
-		WelcomeMessage        string
-		GoodbyeMessage        string
-		MemberAddMessage      string
-		MemberRemoveMessage   string
-		WelcomeChannel        *discordgo.Channel
-		GoodbyeChannel        *discordgo.Channel
-		MemberAddChannel      *discordgo.Channel
-		MemberRemoveChannel   *discordgo.Channel
-		MessageEditChannel    *discordgo.Channel
-		MessageDeleteChannel  *discordgo.Channel
-		ModerationLogsChannel *discordgo.Channel
-		GuildUser             map[string]GuildUser
-		BlacklistedUsers      []*discordgo.User
-		BlacklistedChannels   []*discordgo.Channel
-		AutoRole              []*discordgo.Role
-		MutedRole             *discordgo.Role
-		DisabledCommands      []Command
+		Guild               *discordgo.Guild
+		GuildPrefix         string
+		Prefixes            []string
+		CaseInsensitive     bool
+		WelcomeMessage      string
+		GoodbyeMessage      string
+		MemberAddMessage    string
+		MemberRemoveMessage string
+		WelcomeChannel      *discordgo.Channel
+		WelcomeMode         string
+		WelcomeEmbed        WelcomeEmbedSettings
+		WelcomeDMMessage    string
+		Onboarding          OnboardingSettings
+		ReactionRoles       map[string]*ReactionRoleMessage
+		SelfRoles           []SelfRole
+		GoodbyeChannel      *discordgo.Channel
+		LogChannels         map[string]*discordgo.Channel
+		DefaultLogChannel   *discordgo.Channel
+		WebhookLogs         bool
+		GuildUser           map[string]GuildUser
+		BlacklistedUsers    []*discordgo.User
+		BlacklistedChannels []*discordgo.Channel
+		AutoRole            []*discordgo.Role
+		MutedRole           *discordgo.Role
+		StickyRoles         StickyRoleSettings
+		NameModeration      NameModerationSettings
+		CommandAliases      map[string]string
+		CommandNames        map[string]string
+		DisabledCommands    []string
+		Tags                map[string]*Tag
+		AutomodExemptions   AutomodExemptions
+		MessageLog          MessageLogSettings
+
+		// Deprecated: migrated into LogChannels when the guild is unpacked
+		MemberAddChannel      *discordgo.Channel `json:",omitempty"`
+		MemberRemoveChannel   *discordgo.Channel `json:",omitempty"`
+		MessageEditChannel    *discordgo.Channel `json:",omitempty"`
+		MessageDeleteChannel  *discordgo.Channel `json:",omitempty"`
+		ModerationLogsChannel *discordgo.Channel `json:",omitempty"`
+		AuditLogChannel       *discordgo.Channel `json:",omitempty"`
+	}
+
+	// AutomodExemptions of roles, channels, and permissions ignored by automated moderation
+	AutomodExemptions struct {
+		Roles       []*discordgo.Role
+		Permissions []string
+	}
+
+	// WelcomeEmbedSettings of the embed and card welcome modes
+	WelcomeEmbedSettings struct {
+		Title       string
+		Description string
+		Color       int
+	}
+
+	// OnboardingSettings of the join verification flow
+	OnboardingSettings struct {
+		Enabled         bool
+		UnverifiedRole  *discordgo.Role
+		Channel         *discordgo.Channel
+		Prompt          string
+		PromptMessageID string
+		Emoji           string
+		Answer          string
+	}
+
+	// Tag is a custom text command of a guild
+	Tag struct {
+		Name    string
+		Content string
+		Embed   bool
+		OwnerID string
+		Created time.Time
+	}
+
+	// ReactionRoleMessage binds emojis on a message to roles
+	ReactionRoleMessage struct {
+		ChannelID string
+		MessageID string
+		Mode      string
+		Roles     map[string]*discordgo.Role
+	}
+
+	// SelfRole members can assign themselves, at most one role per non-empty group
+	SelfRole struct {
+		Role  *discordgo.Role
+		Group string
+	}
+
+	// StickyRoleSettings of the roles restored when a member rejoins, every role if Roles is empty
+	StickyRoleSettings struct {
+		Enabled bool
+		Roles   []*discordgo.Role
+	}
+
+	// NameModerationSettings of the nickname rules applied on join and on nickname changes
+	NameModerationSettings struct {
+		Dehoist       bool
+		Normalize     bool
+		BlockedNames  []string
+		ModeratedName string
 	}
 
 	// GuildUser information
 	GuildUser struct {
-		User      *discordgo.User
-		Member    *discordgo.Member
-		Age       string
-		JoinedAt  string
-		Muted     Muted
-		Usernames map[int64]Usernames
-		Nicknames map[int64]Nicknames
-		Warnings  map[int64]Warnings
-		Kicks     map[int64]Kicks
-		Bans      map[int64]Bans
-		Mutes     map[int64]Mutes
+		User        *discordgo.User
+		Member      *discordgo.Member
+		Age         string
+		JoinedAt    string
+		Muted       Muted
+		StickyRoles []string
+		Usernames   map[int64]Usernames
+		Nicknames   map[int64]Nicknames
+		Warnings    map[int64]Warnings
+		Kicks       map[int64]Kicks
+		Bans        map[int64]Bans
+		Mutes       map[int64]Mutes
 	}
 
 	// Warnings information for a user
@@ -100,6 +183,7 @@ type (
 	// Nicknames of user
 	Nicknames struct {
 		Nickname string
+		Reason   string `json:",omitempty"`
 		Time     time.Time
 	}
 
@@ -111,17 +195,38 @@ type (
 	}
 )
 
-// DialNewPool connectes to a local Redis database by port pass-in.
+// Redis pool limits. Commands wait for a free connection once MaxActive are in use.
+const (
+	RedisMaxIdle     = 16
+	RedisMaxActive   = 64
+	RedisIdleTimeout = 4 * time.Minute
+)
+
+// pooledConn runs every command on its own connection from the pool. A single redis connection
+// cannot be used by several goroutines at once, while commands and events run concurrently.
+type pooledConn struct {
+	pool *redis.Pool
+}
+
+// Do :
+// Runs a redis command on a connection from the pool.
+func (c pooledConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	return conn.Do(cmd, args...)
+}
+
+// DialNewPool connectes to a local Redis database by port pass-in. Connections are dialed when
+// first used, and a failed dial is returned as the error of that command.
 func DialNewPool(net string, port string) *redis.Pool {
 	return &redis.Pool{
-		MaxIdle:   80,
-		MaxActive: 12000,
+		MaxIdle:     RedisMaxIdle,
+		MaxActive:   RedisMaxActive,
+		IdleTimeout: RedisIdleTimeout,
+		Wait:        true,
 		Dial: func() (redis.Conn, error) {
-			c, err := redis.Dial(net, port)
-			if err != nil {
-				panic(err.Error())
-			}
-			return c, err
+			return redis.Dial(net, port)
 		},
 	}
 }
@@ -129,14 +234,12 @@ func DialNewPool(net string, port string) *redis.Pool {
 // DialNewPoolURL connectes to a Redis database by URL pass-in.
 func DialNewPoolURL(url string) *redis.Pool {
 	return &redis.Pool{
-		MaxIdle:   80,
-		MaxActive: 12000, // max number of connections
+		MaxIdle:     RedisMaxIdle,
+		MaxActive:   RedisMaxActive,
+		IdleTimeout: RedisIdleTimeout,
+		Wait:        true,
 		Dial: func() (redis.Conn, error) {
-			c, err := redis.DialURL(os.Getenv(url))
-			if err != nil {
-				panic(err.Error())
-			}
-			return c, err
+			return redis.DialURL(os.Getenv(url))
 		},
 	}
 }
@@ -159,6 +262,9 @@ func UnpackGuildStruct(guildID string) (Guild, error) {
 		log.Println(err)
 	}
 
+	g.migrateLogChannels()
+	g.migrateTemplates()
+
 	return g, nil
 }
 
@@ -185,6 +291,13 @@ func PackGuildStruct(guildID string, g Guild) error {
 // DeleteGuild :
 // Removes a guild from the database.
 func DeleteGuild(guild *discordgo.Guild) (interface{}, error) {
+
+	// Remove the guild's persistent message log
+	PurgeStoredMessages(guild.ID)
+
+	// Remove the guild's tag use counts
+	p.Do("DEL", tagUsesKey(guild.ID))
+
 	n, err := p.Do("DEL", guild.ID)
 	if err != nil {
 		log.Println(err)
@@ -216,11 +329,14 @@ func RegisterNewGuild(guild *discordgo.Guild) (interface{}, error) {
 	g := &Guild{
 		Guild:               guild,
 		GuildPrefix:         conf.Prefix,
-		WelcomeMessage:      "Welcome $MEMBER_MENTION$ to $GUILD_NAME$! Enjoy your stay.",
-		GoodbyeMessage:      "Goodbye, `$MEMBER_NAME$`!",
-		MemberAddMessage:    "✅ | `$MEMBER_NAME&` (ID: $MEMBER_ID$ | Age: $MEMBER_AGE$) has joinied the guild.",
-		MemberRemoveMessage: "❌ | `$MEMBER_NAME&` (ID: $MEMBER_ID$ | Age: $MEMBER_AGE$ | Joined At: $MEMBER_JOINED$) has left the guild.",
+		WelcomeMessage:      DefaultWelcomeMessage,
+		GoodbyeMessage:      DefaultGoodbyeMessage,
+		MemberAddMessage:    DefaultMemberAddMessage,
+		MemberRemoveMessage: DefaultMemberRemoveMessage,
+		LogChannels:         make(map[string]*discordgo.Channel),
 		GuildUser:           make(map[string]GuildUser),
+		CommandAliases:      make(map[string]string),
+		CommandNames:        make(map[string]string),
 	}
 
 	serialized, err := json.Marshal(g)
@@ -619,10 +735,13 @@ func FormatNicknames(nicknames map[int64]Nicknames) string {
 
 	str := "\n"
 	for _, v := range keys {
-		str = str + fmt.Sprintf(
-			"**Nickname**:\t%s\n"+
-				"**Time**:\t\t%s\n\n",
-			nicknames[v].Nickname, nicknames[v].Time.Format("01/02/06 03:04:05 PM MST"))
+		str = str + fmt.Sprintf("**Nickname**:\t%s\n", nicknames[v].Nickname)
+
+		if len(nicknames[v].Reason) != 0 {
+			str = str + fmt.Sprintf("**Moderated**:\t%s\n", nicknames[v].Reason)
+		}
+
+		str = str + fmt.Sprintf("**Time**:\t\t%s\n\n", nicknames[v].Time.Format("01/02/06 03:04:05 PM MST"))
 	}
 
 	return str
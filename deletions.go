@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gomodule/redigo/redis"
+)
+
+/**
+ * deletions.go
+ * Chase Weaver
+ *
+ * This package handles the delayed message deletion queue. Deletions are kept in a redis sorted
+ * set by due time so they survive restarts, and due messages are bulk deleted per channel.
+ */
+
+// Deletion queue settings
+const (
+	DeletionQueueKey      = "deletions"
+	DeletionQueueInterval = 500 * time.Millisecond
+	MaxBulkDelete         = 100
+	BulkDeleteMaxAge      = 14 * 24 * time.Hour
+	MaxDueDeletions       = 1000
+	DeletionRetryDelay    = 30 * time.Second
+)
+
+// popDueDeletions takes the due deletions off the queue in one step, so a deletion rescheduled
+// while they are being deleted is kept
+var popDueDeletions = redis.NewScript(1, `
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+if #due > 0 then
+	redis.call('ZREM', KEYS[1], unpack(due))
+end
+return due
+`)
+
+// ScheduleDeletion :
+// Queues a message to be deleted after a delay. Scheduling the same message again only moves
+// its due time.
+func ScheduleDeletion(channelID, messageID string, delay time.Duration) error {
+	due := time.Now().Add(delay).UnixNano() / int64(time.Millisecond)
+
+	conn := pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("ZADD", DeletionQueueKey, due, channelID+":"+messageID)
+	return err
+}
+
+// StartDeletionQueue :
+// Deletes due messages until the bot shuts down, including those left over from a restart.
+func StartDeletionQueue(s *discordgo.Session) {
+	tick := time.NewTicker(DeletionQueueInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			ProcessDeletions(s)
+		case <-botCtx.Done():
+			return
+		}
+	}
+}
+
+// ProcessDeletions :
+// Takes the due messages off the queue and deletes them, batched by channel. Deletions that
+// fail with a rate limit, server or network error are queued again.
+func ProcessDeletions(s *discordgo.Session) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+
+	conn := pool.Get()
+	due, err := redis.Strings(popDueDeletions.Do(conn, DeletionQueueKey, now, MaxDueDeletions))
+	conn.Close()
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if len(due) == 0 {
+		return
+	}
+
+	channels := make(map[string][]string)
+	for _, v := range due {
+		ids := strings.SplitN(v, ":", 2)
+		if len(ids) != 2 {
+			continue
+		}
+		channels[ids[0]] = append(channels[ids[0]], ids[1])
+	}
+
+	for channelID, messages := range channels {
+		for _, id := range DeleteMessages(s, channelID, messages) {
+			err = ScheduleDeletion(channelID, id, DeletionRetryDelay)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
+
+// DeleteMessages :
+// Deletes messages from a channel, in bulk where possible. Messages that are already deleted
+// are skipped. Returns the messages whose deletion failed but may succeed if retried.
+func DeleteMessages(s *discordgo.Session, channelID string, messages []string) []string {
+
+	// Bulk delete only takes 2 to 100 messages younger than two weeks
+	var bulk, single []string
+	for _, id := range messages {
+		created, err := CreationTime(id)
+		if err == nil && time.Since(created) < BulkDeleteMaxAge {
+			bulk = append(bulk, id)
+		} else {
+			single = append(single, id)
+		}
+	}
+
+	for len(bulk) != 0 {
+		n := len(bulk)
+		if n > MaxBulkDelete {
+			n = MaxBulkDelete
+		}
+
+		chunk := bulk[:n]
+		bulk = bulk[n:]
+
+		if len(chunk) == 1 {
+			single = append(single, chunk...)
+			continue
+		}
+
+		err := s.ChannelMessagesBulkDelete(channelID, chunk)
+		if err != nil {
+			log.Println(err)
+			single = append(single, chunk...)
+		}
+	}
+
+	var failed []string
+	for _, id := range single {
+		err := s.ChannelMessageDelete(channelID, id)
+		if err != nil && !isNotFound(err) {
+			log.Println(fmt.Errorf("deleting message %s in %s: %v", id, channelID, err))
+
+			if isTransient(err) {
+				failed = append(failed, id)
+			}
+		}
+	}
+
+	return failed
+}
+
+// isNotFound :
+// Checks if a REST error is a 404, i.e. the message or channel is already gone.
+func isNotFound(err error) bool {
+	restErr, ok := err.(*discordgo.RESTError)
+	return ok && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
+
+// isTransient :
+// Checks if a REST error may pass on its own, i.e. a rate limit, server or network error.
+func isTransient(err error) bool {
+	restErr, ok := err.(*discordgo.RESTError)
+	if !ok || restErr.Response == nil {
+		return true
+	}
+	return restErr.Response.StatusCode == http.StatusTooManyRequests || restErr.Response.StatusCode >= http.StatusInternalServerError
+}
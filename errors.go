@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+/**
+ * errors.go
+ * Chase Weaver
+ *
+ * This package handles recovering from command panics and reporting them with a short error ID
+ * to the bot owner's error channel, or to a local file.
+ */
+
+// DefaultErrorLogFile is written to when no error channel is configured
+const DefaultErrorLogFile = "errors.log"
+
+// RecoverCommand :
+// Recovers from panics in the rest of the chain, replying with an error ID and reporting the
+// panic with its stack.
+func RecoverCommand(ctx Context, next func(Context)) {
+	defer func() {
+		if r := recover(); r != nil {
+			id := NewErrorID()
+			ReportError(ctx, id, r, debug.Stack())
+
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | Something went wrong running that command. Error ID: `%s`", id))
+		}
+	}()
+
+	next(ctx)
+}
+
+// NewErrorID :
+// Returns a short random ID to match an error reply to its report.
+func NewErrorID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
+	}
+	return hex.EncodeToString(b)
+}
+
+// FormatErrorReport :
+// Returns the full details of a command error and the context it happened in.
+func FormatErrorReport(ctx Context, id string, r interface{}, stack []byte) string {
+	guild := "DM"
+	if ctx.Guild != nil {
+		guild = fmt.Sprintf("%s / %s", ctx.Guild.Name, ctx.Guild.ID)
+	}
+
+	return fmt.Sprintf(
+		"Error ID:  %s\n"+
+			"Time:      %s\n"+
+			"Command:   %s\n"+
+			"User:      %s#%s / %s\n"+
+			"Guild:     %s\n"+
+			"Channel:   %s\n"+
+			"Message:   %s\n"+
+			"Error:     %v\n\n%s",
+		id, time.Now().Format(time.RFC3339), ctx.Command.Path(),
+		ctx.Event.Author.Username, ctx.Event.Author.Discriminator, ctx.Event.Author.ID,
+		guild, ctx.Channel.ID, ctx.Event.Content, r, stack)
+}
+
+// ReportError :
+// Logs a command error and sends its report to the error channel, or appends it to the error
+// log file if there is no error channel or it cannot be reached.
+func ReportError(ctx Context, id string, r interface{}, stack []byte) {
+	report := FormatErrorReport(ctx, id, r, stack)
+	log.Printf("\n%s\n", report)
+
+	if len(conf.ErrorChannelID) != 0 {
+		_, err := ctx.Session.ChannelMessageSendComplex(conf.ErrorChannelID, &discordgo.MessageSend{
+			Content: fmt.Sprintf("⚠️ | Error `%s` in `%s`: `%v`", id, ctx.Command.Path(), r),
+			Files: []*discordgo.File{{
+				Name:        fmt.Sprintf("error-%s.txt", id),
+				ContentType: "text/plain",
+				Reader:      bytes.NewBufferString(report),
+			}},
+		})
+
+		if err == nil {
+			return
+		}
+		log.Println(err)
+	}
+
+	path := conf.ErrorLogFile
+	if len(path) == 0 {
+		path = DefaultErrorLogFile
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(report + "\n\n")
+	if err != nil {
+		log.Println(err)
+	}
+}
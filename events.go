@@ -1,15 +1,15 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/gomodule/redigo/redis"
 )
 
 /**
@@ -27,8 +27,8 @@ func MessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// Loads Message into temp cache
 	c.Set(m.ID, m.Message, 0)
 
-	// Default bot prefix
-	prefix := conf.Prefix
+	// Default bot settings outside of guilds
+	g := Guild{GuildPrefix: conf.Prefix}
 
 	// Fetches channel object
 	channel, err := s.State.Channel(m.ChannelID)
@@ -47,28 +47,69 @@ func MessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 		// Registers a new guild if not done already
 		RegisterNewGuild(guild)
 
-		data, err := redis.Bytes(p.Do("GET", guild.ID))
+		g, err = UnpackGuildStruct(guild.ID)
 
 		if err != nil {
 			log.Println(err)
 		}
 
-		var g Guild
-		err = json.Unmarshal(data, &g)
-		prefix = g.GuildPrefix
+		// Stores the message in the persistent message log
+		if g.MessageLog.Enabled && !m.Author.Bot {
+			err = StoreMessage(g, m.Message)
+
+			if err != nil {
+				log.Println(err)
+			}
+		}
+
+		// Caches image attachments so they can be re-uploaded once deleted
+		if g.LogChannelFor(LogEventMessageDelete) != nil && len(m.Attachments) != 0 && !m.Author.Bot {
+			go CacheAttachments(guild.ID, m.Message)
+		}
+
+		// Verifies members answering the onboarding prompt
+		if HandleOnboardingAnswer(s, g, m.Message) {
+			return
+		}
+	}
+
+	// Checks if message content begins with a prefix or a mention of the bot
+	prefix, content, mention, ok := MatchPrefix(s, g, m.Content)
+	if !ok {
+		return
 	}
 
-	// Checks if message content begins with prefix
-	if !strings.HasPrefix(m.Content, prefix) {
+	// A bare mention replies with the prefixes
+	if mention && len(content) == 0 {
+		if !m.Author.Bot {
+			s.ChannelMessageSend(m.ChannelID, FormatPrefixes(g))
+		}
 		return
 	}
 
+	// Mentions show the guild prefix in usage messages
+	if mention {
+		prefix = g.GuildPrefix
+	}
+
+	// The command name runs until the first whitespace
+	name, rest := content, ""
+	if i := strings.IndexFunc(content, unicode.IsSpace); i != -1 {
+		name, rest = content[:i], content[i:]
+	}
+
+	if g.CaseInsensitive {
+		name = strings.ToLower(name)
+	}
+
 	// Give context for command pass-in
 	ctx := Context{
-		Session: s,
-		Event:   m,
-		Channel: channel,
-		Name:    strings.Split(strings.TrimPrefix(m.Content, prefix), " ")[0],
+		Session:  s,
+		Event:    m,
+		Settings: g,
+		Channel:  channel,
+		Name:     name,
+		Prefix:   prefix,
 	}
 
 	// Fetches guild object if text channel is NOT a DM
@@ -79,63 +120,54 @@ func MessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 		}
 
 		ctx.Guild = guild
-
-		// Registers a new guild if not done already
-		RegisterNewGuild(ctx.Guild)
-
-		data, err := redis.Bytes(p.Do("GET", guild.ID))
-
-		if err != nil {
-			log.Println(err)
-		}
-
-		var g Guild
-		err = json.Unmarshal(data, &g)
-		prefix = g.GuildPrefix
 	}
 
-	// Returns a valid command using a name/alias
-	ctx.Command = FetchCommand(ctx.Name)
-
-	// Splits command arguments
-	tmp := strings.TrimPrefix(m.Content, prefix)
+	// Returns a valid command using a name/alias, falling through to the guild's tags
+	ctx.Command = FetchCommand(g, ctx.Name)
+	if t, ok := g.Tags[ctx.Name]; ok && ctx.Command.isEmpty() {
+		ctx.Command = TagCommand(t)
+	}
 
-	// Splits the arguments by the deliminator
-	ctx.Args = strings.Split(tmp, ctx.Command.ArgsDelim)[1:]
+	// Raw arguments following the command name, split like a shell
+	ctx.raw = strings.TrimLeftFunc(rest, unicode.IsSpace)
+	ctx.tokens = Tokenize(ctx.raw)
 
-	// Checks if the config for the command passes all checks and is part of a text channel in a guild
-	if ctx.Channel.Type == discordgo.ChannelTypeGuildText && !CommandIsValid(ctx) {
-		return
-	}
+	// Routes to the subcommand named by the leading arguments
+	ctx.Command, ctx.tokens = FetchSubcommand(ctx.Command, ctx.tokens)
 
-	// Checks if the command can be ran in a DM or not
-	if ctx.Channel.Type == discordgo.ChannelTypeDM && !Contains(ctx.Command.RunIn, "DM") {
+	// Ignores messages that are not commands
+	if ctx.Command.isEmpty() {
 		return
 	}
 
-	// Fetch command funcs from command properties init()
-	funcs := map[string]interface{}{
-		ctx.Command.Name: ctx.Command.Func,
-	}
-
-	// Log commands to console
-	LogCommands(ctx)
-
-	// Type in channel
-	err = ctx.Session.ChannelTyping(ctx.Channel.ID)
-
-	if err != nil {
-		log.Println(err)
+	// Runs the command through the middleware chain on a worker
+	if !QueueCommand(ctx) {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I am busy right now, please try again in a moment.")
 	}
-
-	// Call command with args pass-in
-	Call(funcs, FetchCommandName(ctx.Name), ctx)
 }
 
 // GuildCreate :
 // Initializes a new guild when the bot is first added.
 func GuildCreate(s *discordgo.Session, m *discordgo.GuildCreate) {
 
+	// Snapshot roles, channels, emojis and settings for the audit log
+	SnapshotGuild(m.Guild)
+
+	// Snapshot invite uses to find the invite new members join with
+	SnapshotInvites(s, m.Guild.ID)
+
+	// Catch up on reaction roles changed while offline
+	go SyncReactionRoles(s, m.Guild.ID)
+
+	// Cache member roles to restore sticky roles from, large guilds send the rest in chunks
+	CacheMemberRoles(m.Guild.ID, m.Guild.Members...)
+	if m.Guild.Large {
+		err := s.RequestGuildMembers(m.Guild.ID, "", 0)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
 	if GuildExists(m.Guild) {
 		return
 	}
@@ -155,6 +187,12 @@ func GuildCreate(s *discordgo.Session, m *discordgo.GuildCreate) {
 			m.Guild.Name, m.Guild.ID))
 }
 
+// GuildMembersChunk :
+// Caches the roles of members sent in chunks for large guilds.
+func GuildMembersChunk(s *discordgo.Session, m *discordgo.GuildMembersChunk) {
+	CacheMemberRoles(m.GuildID, m.Members...)
+}
+
 // GuildDelete :
 // Removes a guild when the bot is removed from a guild.
 func GuildDelete(s *discordgo.Session, m *discordgo.GuildDelete) {
@@ -170,6 +208,8 @@ func GuildDelete(s *discordgo.Session, m *discordgo.GuildDelete) {
 		log.Println(err)
 	}
 
+	ForgetMemberRoles(m.Guild.ID)
+
 	log.Println(
 		fmt.Sprintf(`
 			== Guild Removed ==\n
@@ -200,17 +240,46 @@ func GuildMemberAdd(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
 	}
 
 	// Check for User ID in Guild map, register user if missing
-	if _, ok := g.GuildUser[m.User.ID]; !ok {
-		user := RegisterNewUser(m.User)
-		g.GuildUser[m.User.ID] = user
+	user, ok := g.GuildUser[m.User.ID]
+	if !ok {
+		user = RegisterNewUser(m.User)
+	}
+	user.Member = m.Member
+	g.GuildUser[m.User.ID] = user
+
+	CacheMemberRoles(m.GuildID, m.Member)
+
+	// Restore the roles the member had when they left
+	RestoreStickyRoles(s, &g, m.User.ID)
+
+	// Apply the guild's nickname rules
+	ModerateMemberName(s, &g, m.Member)
+
+	err = PackGuildStruct(m.GuildID, g)
+	if err != nil {
+		log.Println(err)
+	}
+
+	// Find the invite the member joined with
+	invite := FetchUsedInvite(s, m.GuildID)
+
+	// Give the member the unverified role until they accept the rules
+	StartOnboarding(s, g, m.User.ID)
+
+	// Send a formatted direct message to the member
+	if len(g.WelcomeDMMessage) != 0 && !m.User.Bot {
+		err := SendWelcomeDM(s, g, guild, m.Member, invite)
+
+		if err != nil {
+			log.Println(err)
+		}
 	}
 
 	// Send a formatted message to the welcome channel
 	if g.WelcomeChannel != nil && len(g.WelcomeMessage) != 0 {
 
-		// Format welcome message
-		msg := FormatWelcomeGoodbyeMessage(guild, m.Member, g.WelcomeMessage)
-		_, err := s.ChannelMessageSend(g.WelcomeChannel.ID, msg)
+		// Send welcome message as text, embed, or card
+		err := SendWelcomeMessage(s, g, guild, m.Member, invite)
 
 		if err != nil {
 			log.Println(err)
@@ -219,19 +288,63 @@ func GuildMemberAdd(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
 	}
 
 	// Send a formatted message to the welcome logger channel
-	if g.MemberAddChannel != nil && len(g.MemberAddMessage) != 0 {
+	if len(g.MemberAddMessage) != 0 {
 
 		// Format welcome message
-		msg := FormatWelcomeGoodbyeMessage(guild, m.Member, g.MemberAddMessage)
-		_, err := s.ChannelMessageSend(g.MemberAddChannel.ID, msg)
-
-		if err != nil {
-			log.Println(err)
-			return
+		msg := FormatWelcomeGoodbyeMessage(guild, m.Member, invite, g.MemberAddMessage)
+		if len(msg) != 0 {
+			SendGuildLog(s, g, LogEventMemberAdd, &discordgo.MessageSend{Content: msg})
 		}
 	}
 }
 
+// MessageReactionAdd :
+// Triggers on a reaction added to a message visible to the bot.
+// Verifies members reacting to the onboarding prompt and grants reaction roles.
+func MessageReactionAdd(s *discordgo.Session, m *discordgo.MessageReactionAdd) {
+
+	if m.UserID == s.State.User.ID {
+		return
+	}
+
+	channel, err := s.State.Channel(m.ChannelID)
+	if err != nil || channel.Type != discordgo.ChannelTypeGuildText {
+		return
+	}
+
+	g, err := UnpackGuildStruct(channel.GuildID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	HandleOnboardingReaction(s, g, m.MessageReaction)
+	HandleReactionRoleAdd(s, g, m.MessageReaction)
+}
+
+// MessageReactionRemove :
+// Triggers on a reaction removed from a message visible to the bot.
+// Revokes reaction roles.
+func MessageReactionRemove(s *discordgo.Session, m *discordgo.MessageReactionRemove) {
+
+	if m.UserID == s.State.User.ID {
+		return
+	}
+
+	channel, err := s.State.Channel(m.ChannelID)
+	if err != nil || channel.Type != discordgo.ChannelTypeGuildText {
+		return
+	}
+
+	g, err := UnpackGuildStruct(channel.GuildID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	HandleReactionRoleRemove(s, g, m.MessageReaction)
+}
+
 // GuildMemberRemove :
 // Logs member to specified guild channel.
 // Says goodbye to guild member in specified guild channel.
@@ -252,48 +365,57 @@ func GuildMemberRemove(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
 		return
 	}
 
+	// Save the member's roles to restore if they rejoin
+	if SnapshotStickyRoles(&g, m.User) {
+		err = PackGuildStruct(m.GuildID, g)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
 	// Send a formatted message to the goodbye channel
 	if g.GoodbyeChannel != nil && len(g.GoodbyeMessage) != 0 {
 
 		// Format goodbye message
-		msg := FormatWelcomeGoodbyeMessage(guild, m.Member, g.GoodbyeMessage)
-		_, err := s.ChannelMessageSend(g.GoodbyeChannel.ID, msg)
+		msg := FormatWelcomeGoodbyeMessage(guild, m.Member, nil, g.GoodbyeMessage)
+		if len(msg) != 0 {
+			_, err := s.ChannelMessageSend(g.GoodbyeChannel.ID, msg)
 
-		if err != nil {
-			log.Println(err)
-			return
+			if err != nil {
+				log.Println(err)
+				return
+			}
 		}
 
 	}
 
 	// Send a formatted message to the goodbye logger channel
-	if g.MemberRemoveChannel != nil && len(g.MemberRemoveMessage) != 0 {
+	if len(g.MemberRemoveMessage) != 0 {
 
 		// Format goodbye message
-		msg := FormatWelcomeGoodbyeMessage(guild, m.Member, g.MemberRemoveMessage)
-		_, err := s.ChannelMessageSend(g.MemberRemoveChannel.ID, msg)
-
-		if err != nil {
-			log.Println(err)
-			return
+		msg := FormatWelcomeGoodbyeMessage(guild, m.Member, nil, g.MemberRemoveMessage)
+		if len(msg) != 0 {
+			SendGuildLog(s, g, LogEventMemberRemove, &discordgo.MessageSend{Content: msg})
 		}
-
 	}
 }
 
 // MessageDelete :
-// This should be reworked to include > 1024 character limit
 // Logs deleted message to specified guild channel.
 func MessageDelete(s *discordgo.Session, m *discordgo.MessageDelete) {
 
-	// Fetch message from cache
-	msg, found := c.Get(m.ID)
+	// Drop reaction roles bound to the deleted message
+	RemoveReactionRoleMessages(m.GuildID, m.ID)
+
+	// Fetch message from cache or the persistent message log
+	mo, found := FetchLoggedMessage(m.GuildID, m.ID)
 	if !found {
 		return
 	}
 
-	// Get cached message object
-	mo := msg.(*discordgo.Message)
+	// Remove the deleted message from the cache and the persistent message log
+	c.Delete(m.ID)
+	DeleteStoredMessage(m.GuildID, m.ID)
 
 	// Ignore messages deleted by bots
 	if mo.Author.Bot {
@@ -307,42 +429,101 @@ func MessageDelete(s *discordgo.Session, m *discordgo.MessageDelete) {
 		return
 	}
 
+	// Remove cached attachments once they have been logged
+	defer RemoveCachedAttachments(m.GuildID, m.ID)
+
 	// Send deleted message to the guild deleted-channel
-	if g.MessageDeleteChannel != nil {
+	if g.LogChannelFor(LogEventMessageDelete) != nil {
 
-		_, err := s.ChannelMessageSendEmbed(g.MessageDeleteChannel.ID,
-			NewEmbed().
-				SetTitle("Deleted Message").
-				SetColor(deleteColor).
-				SetAuthor(fmt.Sprintf("%s#%s / %s", mo.Author.Username, mo.Author.Discriminator, mo.Author.ID), mo.Author.AvatarURL("256"), mo.Author.AvatarURL("2048")).
-				AddField("Channel", fmt.Sprintf("<#%s>", m.ChannelID)).
-				AddField("Content", mo.Content).
-				SetTimestamp(time.Now().Format(time.RFC3339)).MessageEmbed)
+		embed := NewEmbed().
+			SetTitle("Deleted Message").
+			SetColor(deleteColor).
+			SetAuthor(fmt.Sprintf("%s#%s / %s", mo.Author.Username, mo.Author.Discriminator, mo.Author.ID), mo.Author.AvatarURL("256"), mo.Author.AvatarURL("2048")).
+			AddField("Channel", fmt.Sprintf("<#%s>", m.ChannelID))
 
-		if err != nil {
-			log.Println(err)
-			return
+		files := AddMessageContent(embed, "Content", m.GuildID, mo, true)
+
+		SendGuildLog(s, g, LogEventMessageDelete, &discordgo.MessageSend{
+			Embed: embed.SetTimestamp(time.Now().Format(time.RFC3339)).MessageEmbed,
+			Files: files,
+		})
+	}
+}
+
+// MessageDeleteBulk :
+// Logs a transcript of bulk deleted (purged) messages to specified guild channel.
+func MessageDeleteBulk(s *discordgo.Session, m *discordgo.MessageDeleteBulk) {
+
+	// Drop reaction roles bound to the deleted messages
+	RemoveReactionRoleMessages(m.GuildID, m.Messages...)
+
+	// Fetch messages from cache or the persistent message log
+	var messages []*discordgo.Message
+	for _, v := range m.Messages {
+		if mo, found := FetchLoggedMessage(m.GuildID, v); found {
+			messages = append(messages, mo)
 		}
 
+		// Remove the deleted message from the cache and the persistent message log
+		c.Delete(v)
+		DeleteStoredMessage(m.GuildID, v)
+		RemoveCachedAttachments(m.GuildID, v)
+	}
+
+	// Fetch Guild information from redis database
+	g, err := UnpackGuildStruct(m.GuildID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if g.LogChannelFor(LogEventBulkDelete) == nil {
+		return
 	}
+
+	// Sort the messages by time
+	sort.Slice(messages, func(i, j int) bool {
+		a, _ := strconv.ParseUint(messages[i].ID, 10, 64)
+		b, _ := strconv.ParseUint(messages[j].ID, 10, 64)
+		return a < b
+	})
+
+	transcript := FormatTranscript(messages)
+	filename := fmt.Sprintf("transcript-%s-%d.txt", m.ChannelID, MakeTimestamp())
+
+	SendGuildLog(s, g, LogEventBulkDelete, &discordgo.MessageSend{
+		Embed: NewEmbed().
+			SetTitle("Bulk Deleted Messages").
+			SetColor(deleteColor).
+			AddField("Channel", fmt.Sprintf("<#%s>", m.ChannelID)).
+			AddField("Messages", fmt.Sprintf("%d deleted, %d logged in `%s`", len(m.Messages), len(messages), filename)).
+			SetTimestamp(time.Now().Format(time.RFC3339)).MessageEmbed,
+		Files: []*discordgo.File{
+			{
+				Name:   filename,
+				Reader: strings.NewReader(transcript),
+			},
+		},
+	})
 }
 
 // MessageUpdate :
-// This should be reworked to include > 1024 character limit
 // Logs edited message to specified guild channel.
 func MessageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
 
-	// Fetch message from cache
-	msg, found := c.Get(m.ID)
-	if !found {
+	// Ignore updates that are not edits, i.e. embeds unfurling
+	if m.EditedTimestamp == "" {
 		return
 	}
 
-	// Get cached message object
-	mo := msg.(*discordgo.Message)
+	// Fetch message from cache or the persistent message log
+	mo, found := FetchLoggedMessage(m.GuildID, m.ID)
+	if !found {
+		return
+	}
 
-	// Ignore messages edited by bots
-	if mo.Author.Bot {
+	// Ignore messages edited by bots, and edits that do not change the message
+	if mo.Author.Bot || !MessageChanged(mo, m.Message) {
 		return
 	}
 
@@ -353,24 +534,37 @@ func MessageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
 		return
 	}
 
-	// Send edited message to the guild edited-channel
-	if g.MessageEditChannel != nil {
-
-		_, err := s.ChannelMessageSendEmbed(g.MessageEditChannel.ID,
-			NewEmbed().
-				SetTitle("Edited Message").
-				SetColor(editColor).
-				SetAuthor(fmt.Sprintf("%s#%s / %s", mo.Author.Username, mo.Author.Discriminator, mo.Author.ID), mo.Author.AvatarURL("256"), mo.Author.AvatarURL("2048")).
-				AddField("Channel", fmt.Sprintf("<#%s>", m.ChannelID)).
-				AddField("Old Content", mo.Content).
-				AddField("New Content", m.Content).
-				SetTimestamp(time.Now().Format(time.RFC3339)).MessageEmbed)
+	// Keep the edited content for the next edit
+	edited := *mo
+	edited.Content = m.Content
+	edited.Attachments = m.Attachments
+	edited.Embeds = m.Embeds
+	c.Set(m.ID, &edited, 0)
+
+	if g.MessageLog.Enabled {
+		err = UpdateStoredMessage(m.GuildID, &edited)
 
 		if err != nil {
 			log.Println(err)
-			return
 		}
+	}
 
+	// Send edited message to the guild edited-channel
+	if g.LogChannelFor(LogEventMessageEdit) != nil {
+
+		embed := NewEmbed().
+			SetTitle("Edited Message").
+			SetColor(editColor).
+			SetAuthor(fmt.Sprintf("%s#%s / %s", mo.Author.Username, mo.Author.Discriminator, mo.Author.ID), mo.Author.AvatarURL("256"), mo.Author.AvatarURL("2048")).
+			AddField("Channel", fmt.Sprintf("<#%s>", m.ChannelID))
+
+		files := AddMessageContent(embed, "Old Content", m.GuildID, mo, false)
+		files = append(files, AddMessageContent(embed, "New Content", m.GuildID, &edited, false)...)
+
+		SendGuildLog(s, g, LogEventMessageEdit, &discordgo.MessageSend{
+			Embed: embed.SetTimestamp(time.Now().Format(time.RFC3339)).MessageEmbed,
+			Files: files,
+		})
 	}
 }
 
@@ -436,7 +630,7 @@ func GuildMemberUpdate(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
 			Time:     time.Now(),
 		}
 
-		user.Nicknames[MakeTimestamp()] = nickname
+		user.Nicknames[NicknameKey(user.Nicknames)] = nickname
 	} else {
 
 		// Map keys to array
@@ -463,15 +657,31 @@ func GuildMemberUpdate(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
 				Time:     time.Now(),
 			}
 
-			user.Nicknames[MakeTimestamp()] = nickname
+			user.Nicknames[NicknameKey(user.Nicknames)] = nickname
 		}
 	}
 
+	// Track the member's roles for sticky roles, keeping the previous ones for the audit log
+	var roles []string
+	known := user.Member != nil
+	if known {
+		roles = user.Member.Roles
+	}
+	user.Member = m.Member
 	g.GuildUser[m.User.ID] = user
+	CacheMemberRoles(m.GuildID, m.Member)
+
+	// Apply the guild's nickname rules
+	ModerateMemberName(s, &g, m.Member)
 
 	err = PackGuildStruct(m.GuildID, g)
 	if err != nil {
 		log.Println(err)
 		return
 	}
+
+	// Log role changes to the audit log
+	if known && g.LogChannelFor(LogEventMemberRole) != nil {
+		LogMemberRoleUpdate(s, m, roles)
+	}
 }
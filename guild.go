@@ -1,19 +1,19 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/bwmarrin/discordgo"
-
-	"github.com/gomodule/redigo/redis"
 )
 
 func init() {
 	RegisterNewCommand(Command{
-		Name:            "settings",
+		Name:            "config",
 		Func:            Settings,
 		Enabled:         true,
 		NSFWOnly:        false,
@@ -21,43 +21,30 @@ func init() {
 		IgnoreBots:      true,
 		Cooldown:        0,
 		RunIn:           []string{"Text"},
-		Aliases:         []string{},
-		UserPermissions: []string{"Bot Owner"},
-		ArgsDelim:       " ",
-		Usage:           []string{"[Guild Setting]"},
-		Description:     "Lists guild configurations.",
-	})
-
-	RegisterNewCommand(Command{
-		Name:            "set",
-		Func:            Set,
-		Enabled:         true,
-		NSFWOnly:        false,
-		IgnoreSelf:      true,
-		IgnoreBots:      true,
-		Cooldown:        0,
-		RunIn:           []string{"Text"},
-		Aliases:         []string{},
+		Aliases:         []string{"settings"},
 		UserPermissions: []string{"Bot Owner"},
-		ArgsDelim:       " | ",
-		Usage:           []string{"<Guild Setting>", "<value>"},
-		Description:     "Sets guild configurations.",
-	})
-
-	RegisterNewCommand(Command{
-		Name:            "resetguildsettings",
-		Func:            ResetGuildSettings,
-		Enabled:         true,
-		NSFWOnly:        false,
-		IgnoreSelf:      true,
-		IgnoreBots:      true,
-		Cooldown:        0,
-		RunIn:           []string{"Text"},
-		Aliases:         []string{},
-		UserPermissions: []string{"Bot Owner", "Administrator"},
-		ArgsDelim:       "",
 		Usage:           []string{},
-		Description:     "Resets guild configurations.",
+		Subcommands: []Command{
+			{
+				Name:        "list",
+				Func:        Settings,
+				Aliases:     []string{"show"},
+				Description: "Lists guild configurations.",
+			},
+			{
+				Name:        "set",
+				Func:        Set,
+				Usage:       []string{"<Guild Setting>", "<value>"},
+				Description: "Sets guild configurations.",
+			},
+			{
+				Name:            "reset",
+				Func:            ResetGuildSettings,
+				UserPermissions: []string{"Bot Owner", "Administrator"},
+				Description:     "Resets guild configurations.",
+			},
+		},
+		Description: "Lists, sets and resets guild configurations.",
 	})
 }
 
@@ -65,14 +52,12 @@ func init() {
 func Settings(ctx Context) {
 
 	// Fetch guild settings
-	data, err := redis.Bytes(p.Do("GET", ctx.Guild.ID))
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
 	if err != nil {
-		panic(err.Error())
+		log.Println(err)
+		return
 	}
 
-	var g Guild
-	err = json.Unmarshal(data, &g)
-
 	var blc, blu, ar []string
 	for _, v := range g.BlacklistedChannels {
 		blc = append(blc, v.Name)
@@ -86,65 +71,193 @@ func Settings(ctx Context) {
 		ar = append(ar, v.Name)
 	}
 
+	var er []string
+	for _, v := range g.AutomodExemptions.Roles {
+		er = append(er, v.Name)
+	}
+
 	wc := " "
 	if g.WelcomeChannel != nil {
 		wc = g.WelcomeChannel.Name
 	}
 
+	wm := WelcomeModeText
+	if len(g.WelcomeMode) != 0 {
+		wm = g.WelcomeMode
+	}
+
+	ob := "Disabled"
+	if g.Onboarding.Enabled {
+		ob = "Enabled"
+		if g.Onboarding.UnverifiedRole != nil {
+			ob += " (" + g.Onboarding.UnverifiedRole.Name + ")"
+		}
+	}
+
+	sr := "Disabled"
+	if g.StickyRoles.Enabled {
+		sr = "All"
+		if len(g.StickyRoles.Roles) != 0 {
+			var names []string
+			for _, v := range g.StickyRoles.Roles {
+				names = append(names, v.Name)
+			}
+			sr = strings.Join(names, ", ")
+		}
+	}
+
+	var nm []string
+	if g.NameModeration.Dehoist {
+		nm = append(nm, "Dehoist")
+	}
+	if g.NameModeration.Normalize {
+		nm = append(nm, "Normalize")
+	}
+	if len(g.NameModeration.BlockedNames) != 0 {
+		nm = append(nm, fmt.Sprintf("%d Blocked Names (%s)", len(g.NameModeration.BlockedNames), g.NameModeration.fallback()))
+	}
+
 	gc := " "
 	if g.GoodbyeChannel != nil {
-		gc = g.WelcomeChannel.Name
+		gc = g.GoodbyeChannel.Name
 	}
 
-	dc := " "
-	if g.MessageDeleteChannel != nil {
-		dc = g.MessageDeleteChannel.Name
+	ml := "Disabled"
+	if g.MessageLog.Enabled {
+		ml = fmt.Sprintf("Enabled (%v, %d messages)", g.MessageLog.retention(), g.MessageLog.maxMessages())
 	}
 
-	ec := " "
-	if g.MessageEditChannel != nil {
-		dc = g.MessageEditChannel.Name
+	wl := "Disabled"
+	if g.WebhookLogs {
+		wl = "Enabled"
+	}
+
+	ci := "Disabled"
+	if g.CaseInsensitive {
+		ci = "Enabled"
 	}
 
 	str := fmt.Sprintf(
 		"== %s Configuration ==\n\n"+
 			"Guild Prefix             ::   %s\n"+
+			"Extra Prefixes           ::   %s\n"+
+			"Case Insensitive         ::   %s\n"+
+			"Command Aliases          ::   %d\n"+
+			"Custom Command Names     ::   %d\n"+
+			"Disabled Commands        ::   %s\n"+
+			"Tags                     ::   %d\n"+
 			"Blacklisted Channel      ::   %s\n"+
 			"Blacklisted Members      ::   %s\n"+
 			"Welcome Message          ::   %s\n"+
 			"Welcome Channel          ::   %s\n"+
+			"Welcome Mode             ::   %s\n"+
+			"Welcome DM               ::   %s\n"+
+			"Onboarding               ::   %s\n"+
 			"Goodbye Message          ::   %s\n"+
 			"Goodbye Channel          ::   %s\n"+
-			"Message Deleted Channel  ::   %s\n"+
-			"Message Edited Channel   ::   %s\n"+
+			"%s\n"+
 			"Muted Role               ::   %s\n"+
-			"Auto Roles               ::   %s",
-		g.Guild.Name, g.GuildPrefix, strings.Join(blc, ", "), strings.Join(blu, ", "),
-		g.WelcomeMessage, wc, g.GoodbyeMessage, gc, dc, ec, " ", strings.Join(ar, ", "))
+			"Webhook Logs             ::   %s\n"+
+			"Message Log              ::   %s\n"+
+			"Auto Roles               ::   %s\n"+
+			"Sticky Roles             ::   %s\n"+
+			"Name Moderation          ::   %s\n"+
+			"Exempt Roles             ::   %s\n"+
+			"Exempt Permissions       ::   %s",
+		g.Guild.Name, g.GuildPrefix, strings.Join(g.Prefixes, " "), ci, len(g.CommandAliases), len(g.CommandNames), strings.Join(g.DisabledCommands, ", "), len(g.Tags), strings.Join(blc, ", "), strings.Join(blu, ", "),
+		g.WelcomeMessage, wc, wm, g.WelcomeDMMessage, ob, g.GoodbyeMessage, gc, FormatLogRoutes(g), " ", wl, ml, strings.Join(ar, ", "), sr, strings.Join(nm, ", "),
+		strings.Join(er, ", "), strings.Join(g.AutomodExemptions.Permissions, ", "))
 
 	ctx.Session.ChannelMessageSend(ctx.Channel.ID, FormatString(str, "asciidoc"))
 }
 
+// settingKeys are the multi-word keys of the set command, matched before the value
+var settingKeys = []string{
+	"GUILD PREFIX", "EXTRA PREFIXES", "CASE INSENSITIVE", "BLACKLISTED CHANNEL", "BLACKLISTED CHANNELS",
+	"BLACKLISTED USER", "BLACKLISTED USERS",
+	"WELCOME MESSAGE", "WELCOME CHANNEL", "WELCOME MODE", "WELCOME TITLE", "WELCOME DESCRIPTION",
+	"WELCOME COLOR", "WELCOME COLOUR", "WELCOME DM", "WELCOME DM MESSAGE", "UNVERIFIED ROLE",
+	"ONBOARDING CHANNEL", "ONBOARDING PROMPT", "ONBOARDING EMOJI", "ONBOARDING ANSWER", "GOODBYE MESSAGE",
+	"MEMBER ADD MESSAGE", "MEMBER REMOVE MESSAGE", "GOODBYE CHANNEL", "MESSAGE DELETED", "MESSAGE DELETED CHANNEL",
+	"MESSAGE EDITED", "MESSAGE EDITED CHANNEL", "MESSAGE LOG", "WEBHOOK LOGS", "MESSAGE LOG RETENTION",
+	"MESSAGE LOG SIZE", "STICKY ROLES", "DEHOIST NAMES", "NORMALIZE NAMES", "BLOCKED NAMES", "MODERATED NAME",
+	"DISABLED COMMANDS", "MUTED ROLE", "AUTO ROLE", "AUTO ROLES",
+}
+
+// SplitSettingKey :
+// Returns the setting key at the start of the set arguments and how many arguments it spans.
+// The longest known key wins (i.e. WELCOME DM MESSAGE over WELCOME DM), while a quoted first
+// argument is always the whole key. A lone | after the key, the older separator, is skipped.
+func SplitSettingKey(tokens []Token) (string, int) {
+	if len(tokens) == 0 {
+		return "", 0
+	}
+
+	key, n := strings.ToUpper(tokens[0].Value), 1
+	if !tokens[0].Quoted {
+		for _, k := range settingKeys {
+			words := strings.Fields(k)
+			if len(words) <= n || len(words) > len(tokens) {
+				continue
+			}
+
+			match := true
+			for i, w := range words {
+				if tokens[i].Quoted || strings.ToUpper(tokens[i].Value) != w {
+					match = false
+					break
+				}
+			}
+
+			if match {
+				key, n = k, len(words)
+			}
+		}
+	}
+
+	if n < len(tokens) && !tokens[n].Quoted && tokens[n].Value == "|" {
+		n++
+	}
+
+	return key, n
+}
+
 // Set :
 // Allows configration of database guild settings
 func Set(ctx Context) {
 
 	// Fetch guild settings
-	data, err := redis.Bytes(p.Do("GET", ctx.Guild.ID))
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
 	if err != nil {
 		log.Println(err)
+		return
 	}
 
-	var g Guild
-	err = json.Unmarshal(data, &g)
+	// Log channels are routed per event with `config set log <event> <#channel|none>`
+	if len(ctx.Args) > 0 && strings.ToUpper(ctx.Args[0]) == "LOG" {
+		err = SetLogRoute(ctx, &g, ctx.Args[1:])
 
-	if err != nil {
-		log.Println(err)
+		if err != nil {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | "+err.Error())
+			return
+		}
+
+		err = PackGuildStruct(ctx.Guild.ID, g)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "✅ | Log channel updated!")
+		return
 	}
 
+	// Get the Guild Setting to configure
+	key, n := SplitSettingKey(ctx.tokens)
+
 	// Return if Guild Setting cannot be found
-	if len(ctx.Args) <= 1 {
-		msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("Invalid settings key/value. Run `%shelp` for more information.", g.GuildPrefix))
+	if len(ctx.Args) <= n {
+		msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("Invalid settings key/value. Run `%shelp config set` for more information.", g.GuildPrefix))
 
 		if err != nil {
 			log.Println(err)
@@ -154,15 +267,30 @@ func Set(ctx Context) {
 		return
 	}
 
-	// Get the Guild Setting to configure
-	key := strings.ToUpper(ctx.Args[0])
-	val := strings.Join(ctx.Args[1:], ctx.Command.ArgsDelim)
+	val := ctx.RawArgs(n)
 
 	switch key {
 	case "PREFIX":
 		fallthrough
 	case "GUILD PREFIX":
 		g.GuildPrefix = val
+	case "PREFIXES":
+		fallthrough
+	case "EXTRA PREFIXES":
+		g.Prefixes = nil
+		if strings.ToUpper(val) != "NONE" {
+			g.Prefixes = strings.Fields(val)
+		}
+	case "CASE INSENSITIVE":
+		switch strings.ToUpper(val) {
+		case "ON", "ENABLE", "ENABLED", "TRUE":
+			g.CaseInsensitive = true
+		case "OFF", "DISABLE", "DISABLED", "FALSE":
+			g.CaseInsensitive = false
+		default:
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please choose `on` or `off`.")
+			return
+		}
 	case "BLACKLISTED CHANNEL":
 		fallthrough
 	case "BLACKLISTED CHANNELS":
@@ -189,6 +317,10 @@ func Set(ctx Context) {
 			g.BlacklistedUsers = append(g.BlacklistedUsers, v)
 		}
 	case "WELCOME MESSAGE":
+		if !validateTemplateSetting(ctx, val) {
+			return
+		}
+
 		g.WelcomeMessage = val
 	case "WELCOME CHANNEL":
 		channels := FetchMessageContentChannels(ctx, val)
@@ -198,8 +330,117 @@ func Set(ctx Context) {
 		}
 
 		g.WelcomeChannel = channels[0]
+	case "WELCOME MODE":
+		switch mode := strings.ToLower(val); mode {
+		case WelcomeModeText, WelcomeModeEmbed, WelcomeModeCard:
+			g.WelcomeMode = mode
+		default:
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | Please choose `%s`, `%s` or `%s`.", WelcomeModeText, WelcomeModeEmbed, WelcomeModeCard))
+			return
+		}
+	case "WELCOME TITLE":
+		if !validateTemplateSetting(ctx, val) {
+			return
+		}
+
+		g.WelcomeEmbed.Title = val
+	case "WELCOME DESCRIPTION":
+		if !validateTemplateSetting(ctx, val) {
+			return
+		}
+
+		g.WelcomeEmbed.Description = val
+	case "WELCOME COLOR":
+		fallthrough
+	case "WELCOME COLOUR":
+		clr, err := strconv.ParseInt(strings.TrimPrefix(val, "#"), 16, 32)
+
+		if err != nil || clr < 0 || clr > 0xffffff {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please give a hex color, i.e. `#7289DA`.")
+			return
+		}
+
+		g.WelcomeEmbed.Color = int(clr)
+	case "WELCOME DM":
+		fallthrough
+	case "WELCOME DM MESSAGE":
+		if strings.ToLower(val) == "none" {
+			g.WelcomeDMMessage = ""
+			break
+		}
+
+		if !validateTemplateSetting(ctx, val) {
+			return
+		}
+
+		g.WelcomeDMMessage = val
+	case "ONBOARDING":
+		switch strings.ToUpper(val) {
+		case "ON", "ENABLE", "ENABLED", "TRUE":
+			if g.Onboarding.UnverifiedRole == nil {
+				ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please set the `Unverified Role` first.")
+				return
+			}
+
+			g.Onboarding.Enabled = true
+		case "OFF", "DISABLE", "DISABLED", "FALSE":
+			g.Onboarding.Enabled = false
+		default:
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please choose `on` or `off`.")
+			return
+		}
+	case "UNVERIFIED":
+		fallthrough
+	case "UNVERIFIED ROLE":
+		role := FetchMessageContentRoles(ctx, val)
+
+		if len(role) == 0 {
+			return
+		}
+
+		g.Onboarding.UnverifiedRole = role[0]
+	case "ONBOARDING CHANNEL":
+		channels := FetchMessageContentChannels(ctx, val)
+
+		if len(channels) == 0 {
+			return
+		}
+
+		g.Onboarding.Channel = channels[0]
+	case "ONBOARDING PROMPT":
+		g.Onboarding.Prompt = val
+	case "ONBOARDING EMOJI":
+		if strings.ToLower(val) == "none" {
+			g.Onboarding.Emoji = ""
+			break
+		}
+
+		g.Onboarding.Emoji = ParseReactionEmoji(val)
+	case "ONBOARDING ANSWER":
+		if strings.ToLower(val) == "none" {
+			g.Onboarding.Answer = ""
+			break
+		}
+
+		g.Onboarding.Answer = strings.TrimSpace(val)
 	case "GOODBYE MESSAGE":
+		if !validateTemplateSetting(ctx, val) {
+			return
+		}
+
 		g.GoodbyeMessage = val
+	case "MEMBER ADD MESSAGE":
+		if !validateTemplateSetting(ctx, val) {
+			return
+		}
+
+		g.MemberAddMessage = val
+	case "MEMBER REMOVE MESSAGE":
+		if !validateTemplateSetting(ctx, val) {
+			return
+		}
+
+		g.MemberRemoveMessage = val
 	case "GOODBYE CHANNEL":
 		channels := FetchMessageContentChannels(ctx, val)
 
@@ -217,7 +458,7 @@ func Set(ctx Context) {
 			return
 		}
 
-		g.MessageDeleteChannel = channels[0]
+		g.LogChannels[LogEventMessageDelete] = channels[0]
 	case "MESSAGE EDITED":
 		fallthrough
 	case "MESSAGE EDITED CHANNEL":
@@ -227,11 +468,120 @@ func Set(ctx Context) {
 			return
 		}
 
-		g.MessageEditChannel = channels[0]
+		g.LogChannels[LogEventMessageEdit] = channels[0]
+	case "MESSAGE LOG":
+		switch strings.ToUpper(val) {
+		case "ON", "ENABLE", "ENABLED", "TRUE":
+			g.MessageLog.Enabled = true
+		case "OFF", "DISABLE", "DISABLED", "FALSE":
+			g.MessageLog.Enabled = false
+			PurgeStoredMessages(ctx.Guild.ID)
+		default:
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please choose `on` or `off`.")
+			return
+		}
+	case "WEBHOOK LOGS":
+		switch strings.ToUpper(val) {
+		case "ON", "ENABLE", "ENABLED", "TRUE":
+			g.WebhookLogs = true
+		case "OFF", "DISABLE", "DISABLED", "FALSE":
+			g.WebhookLogs = false
+		default:
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please choose `on` or `off`.")
+			return
+		}
+	case "MESSAGE LOG RETENTION":
+		retention, err := time.ParseDuration(val)
+
+		if err != nil || retention <= 0 {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please give a valid retention window, i.e. `72h`.")
+			return
+		}
+
+		g.MessageLog.Retention = retention
+	case "MESSAGE LOG SIZE":
+		size, err := strconv.Atoi(val)
+
+		if err != nil || size <= 0 {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please give a valid number of messages to keep.")
+			return
+		}
+
+		g.MessageLog.MaxMessages = size
+	case "STICKY ROLES":
+		switch strings.ToUpper(val) {
+		case "ON", "ENABLE", "ENABLED", "TRUE":
+			g.StickyRoles.Enabled = true
+		case "OFF", "DISABLE", "DISABLED", "FALSE":
+			g.StickyRoles.Enabled = false
+		case "ALL":
+			g.StickyRoles.Enabled = true
+			g.StickyRoles.Roles = nil
+		default:
+			roles := FetchMessageContentRoles(ctx, val)
+
+			if len(roles) == 0 {
+				ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please choose `on`, `off`, `all` or give the roles to restore.")
+				return
+			}
+
+			g.StickyRoles.Enabled = true
+			g.StickyRoles.Roles = roles
+		}
+	case "DEHOIST":
+		fallthrough
+	case "DEHOIST NAMES":
+		switch strings.ToUpper(val) {
+		case "ON", "ENABLE", "ENABLED", "TRUE":
+			g.NameModeration.Dehoist = true
+		case "OFF", "DISABLE", "DISABLED", "FALSE":
+			g.NameModeration.Dehoist = false
+		default:
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please choose `on` or `off`.")
+			return
+		}
+	case "NORMALIZE NAMES":
+		switch strings.ToUpper(val) {
+		case "ON", "ENABLE", "ENABLED", "TRUE":
+			g.NameModeration.Normalize = true
+		case "OFF", "DISABLE", "DISABLED", "FALSE":
+			g.NameModeration.Normalize = false
+		default:
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please choose `on` or `off`.")
+			return
+		}
+	case "BLOCKED NAMES":
+		g.NameModeration.BlockedNames = nil
+
+		if strings.ToLower(val) == "none" {
+			break
+		}
+
+		for _, v := range strings.Split(val, ",") {
+			if v = strings.TrimSpace(v); len(v) != 0 {
+				g.NameModeration.BlockedNames = append(g.NameModeration.BlockedNames, v)
+			}
+		}
+	case "MODERATED NAME":
+		if len([]rune(val)) > MaxNicknameLength {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | Nicknames can be at most %d characters.", MaxNicknameLength))
+			return
+		}
+
+		g.NameModeration.ModeratedName = val
 	case "DISABLED":
 		fallthrough
 	case "DISABLED COMMANDS":
-		return
+		if strings.ToLower(val) == "none" {
+			g.DisabledCommands = nil
+			break
+		}
+
+		err := g.SetCommandsDisabled(strings.FieldsFunc(val, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }), true)
+		if err != nil {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | %s.", err))
+			return
+		}
 	case "MUTED":
 		fallthrough
 	case "MUTED ROLE":
@@ -262,18 +612,25 @@ func Set(ctx Context) {
 		return
 	}
 
-	serialized, err := json.Marshal(g)
-
+	err = PackGuildStruct(ctx.Guild.ID, g)
 	if err != nil {
 		log.Println(err)
+		return
 	}
 
-	_, err = p.Do("SET", ctx.Guild.ID, serialized)
-	if err != nil {
-		log.Println(err)
-		return
+}
+
+// validateTemplateSetting :
+// Replies with the template error and the available variables if a template is invalid.
+func validateTemplateSetting(ctx Context, val string) bool {
+	err := ValidateTemplate(val)
+	if err == nil {
+		return true
 	}
 
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Invalid template: "+err.Error()+"\n"+
+		FormatString("== Template Variables ==\n\n"+FormatTemplateVariables(), "asciidoc"))
+	return false
 }
 
 // ResetGuildSettings :
@@ -284,10 +641,11 @@ func ResetGuildSettings(ctx Context) {
 	g := Guild{
 		Guild:               ctx.Guild,
 		GuildPrefix:         conf.Prefix,
-		WelcomeMessage:      "Welcome $MEMBER_MENTION$ to $GUILD_NAME$! Enjoy your stay.",
-		GoodbyeMessage:      "Goodbye, `$MEMBER_NAME$`!",
-		MemberAddMessage:    "✅ | `$MEMBER_NAME&` (ID: $MEMBER_ID$ | Age: $MEMBER_AGE$) has joinied the guild.",
-		MemberRemoveMessage: "❌ | `$MEMBER_NAME&` (ID: $MEMBER_ID$ | Age: $MEMBER_AGE$ | Joined At: $MEMBER_JOINED$) has left the guild.",
+		WelcomeMessage:      DefaultWelcomeMessage,
+		GoodbyeMessage:      DefaultGoodbyeMessage,
+		MemberAddMessage:    DefaultMemberAddMessage,
+		MemberRemoveMessage: DefaultMemberRemoveMessage,
+		LogChannels:         make(map[string]*discordgo.Channel),
 		GuildUser:           make(map[string]GuildUser),
 	}
 
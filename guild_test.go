@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestSplitSettingKey(t *testing.T) {
+	tests := []struct {
+		in  string
+		key string
+		n   int
+	}{
+		{"", "", 0},
+		{"prefix !", "PREFIX", 1},
+		{"guild prefix !", "GUILD PREFIX", 2},
+		{"welcome dm hi", "WELCOME DM", 2},
+		{"welcome dm message hi", "WELCOME DM MESSAGE", 3},
+		{"Welcome Message Hi there", "WELCOME MESSAGE", 2},
+		{"welcome message | Hi", "WELCOME MESSAGE", 3},
+		{"welcome message |", "WELCOME MESSAGE", 3},
+		{`welcome message "|" Hi`, "WELCOME MESSAGE", 2},
+		{`"welcome message" Hi`, "WELCOME MESSAGE", 1},
+		{`"welcome" message Hi`, "WELCOME", 1},
+		{`welcome "message" Hi`, "WELCOME", 1},
+		{"welcome", "WELCOME", 1},
+	}
+
+	for _, tt := range tests {
+		key, n := SplitSettingKey(Tokenize(tt.in))
+		if key != tt.key || n != tt.n {
+			t.Errorf("SplitSettingKey(%q) = %q, %d, want %q, %d", tt.in, key, n, tt.key, tt.n)
+		}
+	}
+}
@@ -1,8 +1,9 @@
 package main
 
 import (
-	"errors"
-	"reflect"
+	"context"
+	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -19,13 +20,22 @@ type (
 
 	// Context of pass-in per command
 	Context struct {
-		Session *discordgo.Session
-		Event   *discordgo.MessageCreate
-		Guild   *discordgo.Guild
-		Channel *discordgo.Channel
-		Command Command
-		Name    string
-		Args    []string
+		Session   *discordgo.Session
+		Event     *discordgo.MessageCreate
+		Guild     *discordgo.Guild
+		Settings  Guild
+		Channel   *discordgo.Channel
+		Command   Command
+		Name      string
+		Prefix    string
+		Ctx       context.Context
+		Args      []string
+		Flags     map[string]string
+		Arguments Arguments
+
+		// Raw arguments and their tokens, see RawArgs
+		raw    string
+		tokens []Token
 	}
 
 	// Command struct per command
@@ -40,9 +50,15 @@ type (
 		RunIn           []string
 		Aliases         []string
 		UserPermissions []string
-		ArgsDelim       string
 		Usage           []string
+		Params          []Param
+		Flags           []Flag
+		Subcommands     []Command
+		Timeout         time.Duration
 		Description     string
+
+		// Full name including the groups the command is nested in, see Path
+		path string
 	}
 
 	// Cooldown data per user for commands
@@ -55,6 +71,42 @@ type (
 var commands = make(map[string]Command)
 var cooldown = make(map[string][]Cooldown)
 
+// permissions maps UserPermissions names to their discordgo permission bits
+var permissions = map[string]int{
+	"Read Messages":         discordgo.PermissionReadMessages,
+	"Send Messages":         discordgo.PermissionSendMessages,
+	"Send TTS Messages":     discordgo.PermissionSendTTSMessages,
+	"Manage Messages":       discordgo.PermissionManageMessages,
+	"Embed Links":           discordgo.PermissionEmbedLinks,
+	"Attach Files":          discordgo.PermissionAttachFiles,
+	"Read Message History":  discordgo.PermissionReadMessageHistory,
+	"Mention Everyone":      discordgo.PermissionMentionEveryone,
+	"Use External Emojis":   discordgo.PermissionUseExternalEmojis,
+	"Voice Connect":         discordgo.PermissionVoiceConnect,
+	"Voice Speak":           discordgo.PermissionVoiceSpeak,
+	"Voice Mute Members":    discordgo.PermissionVoiceMuteMembers,
+	"Voice Deafen Members":  discordgo.PermissionVoiceDeafenMembers,
+	"Voice Move Members":    discordgo.PermissionVoiceMoveMembers,
+	"Voice Use VAD":         discordgo.PermissionVoiceUseVAD,
+	"Change Nickname":       discordgo.PermissionChangeNickname,
+	"Manage Nicknames":      discordgo.PermissionManageNicknames,
+	"Manage Roles":          discordgo.PermissionManageRoles,
+	"Manage Webhooks":       discordgo.PermissionManageWebhooks,
+	"Manage Emojis":         discordgo.PermissionManageEmojis,
+	"Create Instant Invite": discordgo.PermissionCreateInstantInvite,
+	"Kick Members":          discordgo.PermissionKickMembers,
+	"Ban Members":           discordgo.PermissionBanMembers,
+	"Administrator":         discordgo.PermissionAdministrator,
+	"Manage Channels":       discordgo.PermissionManageChannels,
+	"Manage Server":         discordgo.PermissionManageServer,
+	"Add Reactions":         discordgo.PermissionAddReactions,
+	"View Audit Logs":       discordgo.PermissionViewAuditLogs,
+	"All Text":              discordgo.PermissionAllText,
+	"All Voice":             discordgo.PermissionAllVoice,
+	"All Channel":           discordgo.PermissionAllChannel,
+	"All":                   discordgo.PermissionAll,
+}
+
 // IsEmpty ::
 // Simple way to check if a Command is empty
 func (c Command) isEmpty() bool {
@@ -104,10 +156,86 @@ func CommandIsValid(ctx Context) bool {
 // Creates a new command
 func RegisterNewCommand(c Command) {
 	if !HasCommand(c.Name) {
+		inheritSubcommands(&c)
 		commands[c.Name] = c
 	}
 }
 
+// inheritSubcommands :
+// Sets the path of each subcommand and copies the properties it shares with its group.
+// Subcommands with nil UserPermissions take the group's, while an empty list lets anyone run them.
+func inheritSubcommands(c *Command) {
+	for i := range c.Subcommands {
+		sub := &c.Subcommands[i]
+		sub.path = c.Path() + " " + sub.Name
+		sub.Enabled = c.Enabled
+		sub.NSFWOnly = sub.NSFWOnly || c.NSFWOnly
+		sub.IgnoreSelf = c.IgnoreSelf
+		sub.IgnoreBots = c.IgnoreBots
+
+		if len(sub.RunIn) == 0 {
+			sub.RunIn = c.RunIn
+		}
+
+		if sub.UserPermissions == nil {
+			sub.UserPermissions = c.UserPermissions
+		}
+
+		if sub.Cooldown == 0 {
+			sub.Cooldown = c.Cooldown
+		}
+
+		inheritSubcommands(sub)
+	}
+}
+
+// Path :
+// Returns the full name of a command, i.e. config set.
+func (c Command) Path() string {
+	if len(c.path) != 0 {
+		return c.path
+	}
+	return c.Name
+}
+
+// FetchSubcommand :
+// Follows the leading arguments into the subcommands of a command by name or alias, returning
+// the deepest subcommand found and the arguments after it.
+func FetchSubcommand(c Command, tokens []Token) (Command, []Token) {
+	for len(tokens) != 0 && !tokens[0].Quoted {
+		found := false
+
+		for _, sub := range c.Subcommands {
+			if sub.hasName(tokens[0].Value) {
+				c, tokens, found = sub, tokens[1:], true
+				break
+			}
+		}
+
+		if !found {
+			break
+		}
+	}
+
+	return c, tokens
+}
+
+// hasName :
+// Checks if a subcommand goes by a name or alias, ignoring case.
+func (c Command) hasName(name string) bool {
+	if strings.EqualFold(c.Name, name) {
+		return true
+	}
+
+	for _, v := range c.Aliases {
+		if strings.EqualFold(v, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // HasCommand :
 // Checks if a command is already mapped.
 func HasCommand(k string) bool {
@@ -120,8 +248,31 @@ func HasCommand(k string) bool {
 }
 
 // FetchCommand :
-// Returns a valid command.
-func FetchCommand(k string) Command {
+// Returns a valid command by its name or alias in a guild. The guild's custom names and aliases
+// are checked besides the built-in ones, while renamed and disabled commands are not found.
+func FetchCommand(g Guild, k string) Command {
+	c := fetchGuildCommand(g, k)
+
+	if c.isEmpty() || Contains(g.DisabledCommands, c.Name) {
+		return Command{}
+	}
+
+	return c
+}
+
+// FetchCommandName :
+// Returns the name of a valid command in a guild if it exists.
+func FetchCommandName(g Guild, k string) string {
+	if c := FetchCommand(g, k); !c.isEmpty() {
+		return c.Name
+	}
+
+	return k
+}
+
+// FetchBuiltinCommand :
+// Returns a valid command by its built-in name or alias, ignoring guild settings.
+func FetchBuiltinCommand(k string) Command {
 	if HasCommand(commands[k].Name) {
 		return commands[k]
 	}
@@ -137,22 +288,26 @@ func FetchCommand(k string) Command {
 	return Command{}
 }
 
-// FetchCommandName :
-// Returns a valid command if it exists.
-func FetchCommandName(k string) string {
-	if HasCommand(commands[k].Name) {
-		return commands[k].Name
+// fetchGuildCommand :
+// Returns a command by its built-in name or alias, then by a guild's custom name or alias. The
+// built-in name of a renamed command is not found.
+func fetchGuildCommand(g Guild, k string) Command {
+	c := FetchBuiltinCommand(k)
+	if _, renamed := g.CommandNames[c.Name]; !c.isEmpty() && !(renamed && c.Name == k) {
+		return c
 	}
 
-	for key := range commands {
-		for val := range commands[key].Aliases {
-			if commands[key].Aliases[val] == k {
-				return commands[key].Name
-			}
+	for name, custom := range g.CommandNames {
+		if custom == k {
+			return commands[name]
 		}
 	}
 
-	return k
+	if name, ok := g.CommandAliases[k]; ok {
+		return commands[name]
+	}
+
+	return Command{}
 }
 
 // MemberHasPermission :
@@ -171,70 +326,8 @@ func MemberHasPermission(ctx Context, perm string) bool {
 		if ctx.Event.Author.ID == conf.OwnerID {
 			return true
 		}
-	case "Read Messages":
-		permission = discordgo.PermissionReadMessages
-	case "Send Messages":
-		permission = discordgo.PermissionSendMessages
-	case "Send TTS Messages":
-		permission = discordgo.PermissionSendTTSMessages
-	case "Manage Messages":
-		permission = discordgo.PermissionManageMessages
-	case "Embed Links":
-		permission = discordgo.PermissionEmbedLinks
-	case "Attach Files":
-		permission = discordgo.PermissionAttachFiles
-	case "Read Message History":
-		permission = discordgo.PermissionReadMessageHistory
-	case "Mention Everyone":
-		permission = discordgo.PermissionMentionEveryone
-	case "Use External Emojis":
-		permission = discordgo.PermissionUseExternalEmojis
-	case "Voice Connect":
-		permission = discordgo.PermissionVoiceConnect
-	case "Voice Speak":
-		permission = discordgo.PermissionVoiceSpeak
-	case "Voice Mute Members":
-		permission = discordgo.PermissionVoiceMuteMembers
-	case "Voice Deafen Members":
-		permission = discordgo.PermissionVoiceDeafenMembers
-	case "Voice Move Members":
-		permission = discordgo.PermissionVoiceMoveMembers
-	case "Voice Use VAD":
-		permission = discordgo.PermissionVoiceUseVAD
-	case "Change Nickname":
-		permission = discordgo.PermissionChangeNickname
-	case "Manage Nicknames":
-		permission = discordgo.PermissionManageNicknames
-	case "Manage Roles":
-		permission = discordgo.PermissionManageRoles
-	case "Manage Webhooks":
-		permission = discordgo.PermissionManageWebhooks
-	case "Manage Emojis":
-		permission = discordgo.PermissionManageEmojis
-	case "Create Instant Invite":
-		permission = discordgo.PermissionCreateInstantInvite
-	case "Kick Members":
-		permission = discordgo.PermissionKickMembers
-	case "Ban Members":
-		permission = discordgo.PermissionBanMembers
-	case "Administrator":
-		permission = discordgo.PermissionAdministrator
-	case "Manage Channels":
-		permission = discordgo.PermissionManageChannels
-	case "Manage Server":
-		permission = discordgo.PermissionManageServer
-	case "Add Reactions":
-		permission = discordgo.PermissionAddReactions
-	case "View Audit Logs":
-		permission = discordgo.PermissionViewAuditLogs
-	case "All Text":
-		permission = discordgo.PermissionAllText
-	case "All Voice":
-		permission = discordgo.PermissionAllVoice
-	case "All Channel":
-		permission = discordgo.PermissionAllChannel
-	case "All":
-		permission = discordgo.PermissionAll
+	default:
+		permission = permissions[perm]
 	}
 
 	mem, err := ctx.Session.State.Member(ctx.Guild.ID, ctx.Event.Author.ID)
@@ -258,19 +351,3 @@ func MemberHasPermission(ctx Context, perm string) bool {
 
 	return false
 }
-
-// Call :
-// Func based on name and passes Session, MessageCreate, ...args.
-func Call(m map[string]interface{}, name string, params ...interface{}) (result []reflect.Value, err error) {
-	f := reflect.ValueOf(m[name])
-	if len(params) != f.Type().NumIn() {
-		err = errors.New("the number of params is not adapted")
-		return
-	}
-	in := make([]reflect.Value, len(params))
-	for k, param := range params {
-		in[k] = reflect.ValueOf(param)
-	}
-	result = f.Call(in)
-	return
-}
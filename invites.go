@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+/**
+ * invites.go
+ * Chase Weaver
+ *
+ * This package handles tracking guild invite uses to find the invite a member joined with.
+ */
+
+var (
+	inviteUses   = make(map[string]map[string]int)
+	inviteUsesMu sync.Mutex
+)
+
+// SnapshotInvites :
+// Stores the current uses of every guild invite.
+func SnapshotInvites(s *discordgo.Session, guildID string) {
+	invites, err := s.GuildInvites(guildID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	uses := make(map[string]int)
+	for _, v := range invites {
+		uses[v.Code] = v.Uses
+	}
+
+	inviteUsesMu.Lock()
+	inviteUses[guildID] = uses
+	inviteUsesMu.Unlock()
+}
+
+// FetchUsedInvite :
+// Returns the invite whose uses went up since the last snapshot, updating the snapshot.
+// Returns nil if the invite cannot be determined.
+func FetchUsedInvite(s *discordgo.Session, guildID string) *discordgo.Invite {
+	invites, err := s.GuildInvites(guildID)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+
+	inviteUsesMu.Lock()
+	defer inviteUsesMu.Unlock()
+
+	old, known := inviteUses[guildID]
+	uses := make(map[string]int)
+
+	var used []*discordgo.Invite
+	for _, v := range invites {
+		uses[v.Code] = v.Uses
+		if v.Uses > old[v.Code] {
+			used = append(used, v)
+		}
+	}
+
+	inviteUses[guildID] = uses
+
+	// Without an earlier snapshot, or with several candidates, the invite is ambiguous
+	if !known || len(used) != 1 {
+		return nil
+	}
+
+	return used[0]
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+/**
+ * logging.go
+ * Chase Weaver
+ *
+ * This package handles routing guild logs to their configured channels.
+ */
+
+// Log event types routed to guild log channels
+const (
+	LogEventMessageEdit   = "message_edit"
+	LogEventMessageDelete = "message_delete"
+	LogEventBulkDelete    = "bulk_delete"
+	LogEventMemberAdd     = "member_add"
+	LogEventMemberRemove  = "member_remove"
+	LogEventModeration    = "moderation"
+	LogEventRole          = "role"
+	LogEventChannel       = "channel"
+	LogEventBan           = "ban"
+	LogEventEmoji         = "emoji"
+	LogEventGuild         = "guild"
+	LogEventMemberRole    = "member_role"
+)
+
+// LogEventDefault is the route used by events without their own channel
+const LogEventDefault = "default"
+
+// logEvents lists every routable log event type
+var logEvents = []string{
+	LogEventMessageEdit, LogEventMessageDelete, LogEventBulkDelete, LogEventMemberAdd, LogEventMemberRemove, LogEventModeration,
+	LogEventRole, LogEventChannel, LogEventBan, LogEventEmoji, LogEventGuild, LogEventMemberRole,
+}
+
+// auditLogEvents lists the log event types of the guild audit log
+var auditLogEvents = []string{LogEventRole, LogEventChannel, LogEventBan, LogEventEmoji, LogEventGuild, LogEventMemberRole}
+
+// IsLogEvent :
+// Checks if an event type can be routed to a log channel.
+func IsLogEvent(event string) bool {
+	return Contains(logEvents, event)
+}
+
+// LogChannelFor :
+// Returns the channel an event type is logged to, falling back to the default log channel.
+func (g Guild) LogChannelFor(event string) *discordgo.Channel {
+	if ch, ok := g.LogChannels[event]; ok && ch != nil {
+		return ch
+	}
+	return g.DefaultLogChannel
+}
+
+// migrateLogChannels :
+// Moves the fixed per-event log channels of older guild settings into the log routes.
+func (g *Guild) migrateLogChannels() {
+	if g.LogChannels == nil {
+		g.LogChannels = make(map[string]*discordgo.Channel)
+	}
+
+	legacy := map[string]**discordgo.Channel{
+		LogEventMessageEdit:   &g.MessageEditChannel,
+		LogEventMessageDelete: &g.MessageDeleteChannel,
+		LogEventMemberAdd:     &g.MemberAddChannel,
+		LogEventMemberRemove:  &g.MemberRemoveChannel,
+		LogEventModeration:    &g.ModerationLogsChannel,
+	}
+
+	for event, ch := range legacy {
+		if *ch == nil {
+			continue
+		}
+		if _, ok := g.LogChannels[event]; !ok {
+			g.LogChannels[event] = *ch
+		}
+		*ch = nil
+	}
+
+	if g.AuditLogChannel != nil {
+		for _, event := range auditLogEvents {
+			if _, ok := g.LogChannels[event]; !ok {
+				g.LogChannels[event] = g.AuditLogChannel
+			}
+		}
+		g.AuditLogChannel = nil
+	}
+}
+
+// SendGuildLog :
+// Sends a message to the channel an event type is logged to, if any. Embed-only logs are
+// batched through the channel's webhook when webhook logs are enabled.
+func SendGuildLog(s *discordgo.Session, g Guild, event string, data *discordgo.MessageSend) {
+
+	ch := g.LogChannelFor(event)
+	if ch == nil {
+		return
+	}
+
+	if g.WebhookLogs && data.Embed != nil && data.Content == "" && len(data.Files) == 0 {
+		err := QueueWebhookLog(s, ch.ID, data.Embed)
+		if err == nil {
+			return
+		}
+		log.Println(err)
+	}
+
+	_, err := s.ChannelMessageSendComplex(ch.ID, data)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// SendGuildLogEmbed :
+// Sends an embed to the channel an event type is logged to, if any.
+func SendGuildLogEmbed(s *discordgo.Session, g Guild, event string, e *discordgo.MessageEmbed) {
+	SendGuildLog(s, g, event, &discordgo.MessageSend{Embed: e})
+}
+
+// SetLogRoute :
+// Routes an event type (or the default) to a channel, or clears the route with "none".
+// [event] [#channel|none]
+func SetLogRoute(ctx Context, g *Guild, args []string) error {
+
+	if len(args) < 2 {
+		return fmt.Errorf("usage: `config set log <%s|%s> <#channel|none>`", LogEventDefault, strings.Join(logEvents, "|"))
+	}
+
+	event := strings.ToLower(args[0])
+	val := strings.Join(args[1:], " ")
+
+	if event != LogEventDefault && !IsLogEvent(event) {
+		return fmt.Errorf("`%s` is not a log event, choose one of `%s`", event, strings.Join(append([]string{LogEventDefault}, logEvents...), ", "))
+	}
+
+	var ch *discordgo.Channel
+	if strings.ToLower(val) != "none" {
+		channels := FetchMessageContentChannels(ctx, val)
+
+		if len(channels) == 0 {
+			return fmt.Errorf("I cannot find that channel")
+		}
+
+		ch = channels[0]
+	}
+
+	if event == LogEventDefault {
+		g.DefaultLogChannel = ch
+		return nil
+	}
+
+	if ch == nil {
+		delete(g.LogChannels, event)
+	} else {
+		g.LogChannels[event] = ch
+	}
+
+	return nil
+}
+
+// FormatLogRoutes :
+// Returns a string of every log event and the channel it is routed to.
+func FormatLogRoutes(g Guild) string {
+	events := append([]string{}, logEvents...)
+	sort.Strings(events)
+
+	def := " "
+	if g.DefaultLogChannel != nil {
+		def = g.DefaultLogChannel.Name
+	}
+
+	str := fmt.Sprintf("%-24s ::   %s\n", "Log Channel (default)", def)
+
+	for _, v := range events {
+		name := " "
+		if ch, ok := g.LogChannels[v]; ok && ch != nil {
+			name = ch.Name
+		} else if g.DefaultLogChannel != nil {
+			name = "(default)"
+		}
+		str += fmt.Sprintf("%-24s ::   %s\n", "Log "+v, name)
+	}
+
+	return strings.TrimSuffix(str, "\n")
+}
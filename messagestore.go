@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gomodule/redigo/redis"
+)
+
+/**
+ * messagestore.go
+ * Chase Weaver
+ *
+ * This package handles the persistent message log used for logging
+ * deleted and edited messages beyond the in-memory cache.
+ */
+
+// Defaults for the persistent message log
+const (
+	DefaultMessageLogRetention   = 7 * 24 * time.Hour
+	DefaultMessageLogMaxMessages = 10000
+)
+
+// MessageLogSettings :
+// Persistent message log configuration per guild
+type MessageLogSettings struct {
+	Enabled     bool
+	Retention   time.Duration
+	MaxMessages int
+}
+
+// retention :
+// Returns the configured retention window, or the default.
+func (m MessageLogSettings) retention() time.Duration {
+	if m.Retention <= 0 {
+		return DefaultMessageLogRetention
+	}
+	return m.Retention
+}
+
+// maxMessages :
+// Returns the configured per-guild message cap, or the default.
+func (m MessageLogSettings) maxMessages() int {
+	if m.MaxMessages <= 0 {
+		return DefaultMessageLogMaxMessages
+	}
+	return m.MaxMessages
+}
+
+// messageKey :
+// Returns the redis key of a stored message.
+func messageKey(guildID, messageID string) string {
+	return fmt.Sprintf("messages:%s:%s", guildID, messageID)
+}
+
+// messageIndexKey :
+// Returns the redis key of a guild's stored message index, scored by the time each was stored.
+func messageIndexKey(guildID string) string {
+	return fmt.Sprintf("messageindex:%s", guildID)
+}
+
+// StoreMessage :
+// Adds a message to the guild's persistent message log in one round trip, expiring it after the
+// retention window and dropping expired entries and the oldest messages over the size cap.
+func StoreMessage(g Guild, m *discordgo.Message) error {
+
+	serialized, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	guildID := g.Guild.ID
+	retention := g.MessageLog.retention()
+	ttl := int64(retention / time.Second)
+	max := g.MessageLog.maxMessages()
+	now := time.Now()
+
+	conn := pool.Get()
+	defer conn.Close()
+
+	conn.Send("SET", messageKey(guildID, m.ID), serialized, "EX", ttl)
+	conn.Send("ZADD", messageIndexKey(guildID), now.Unix(), m.ID)
+
+	// Remove entries whose messages have expired, then those that fall outside of the size cap
+	conn.Send("ZREMRANGEBYSCORE", messageIndexKey(guildID), "-inf", now.Add(-retention).Unix())
+	conn.Send("ZRANGE", messageIndexKey(guildID), 0, -max-1)
+	conn.Send("ZREMRANGEBYRANK", messageIndexKey(guildID), 0, -max-1)
+	conn.Send("EXPIRE", messageIndexKey(guildID), ttl)
+
+	err = conn.Flush()
+	if err != nil {
+		return err
+	}
+
+	var overflow []string
+	for i := 0; i < 6; i++ {
+		reply, err := conn.Receive()
+		if err != nil {
+			return err
+		}
+
+		if i == 3 {
+			overflow, _ = redis.Strings(reply, nil)
+		}
+	}
+
+	if len(overflow) == 0 {
+		return nil
+	}
+
+	keys := make([]interface{}, len(overflow))
+	for i, v := range overflow {
+		keys[i] = messageKey(guildID, v)
+	}
+
+	_, err = conn.Do("DEL", keys...)
+	return err
+}
+
+// UpdateStoredMessage :
+// Replaces a message already in the guild's persistent message log, keeping its expiry.
+func UpdateStoredMessage(guildID string, m *discordgo.Message) error {
+
+	ttl, err := redis.Int64(p.Do("TTL", messageKey(guildID, m.ID)))
+	if err != nil || ttl <= 0 {
+		return err
+	}
+
+	serialized, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.Do("SET", messageKey(guildID, m.ID), serialized, "EX", ttl)
+	return err
+}
+
+// FetchStoredMessage :
+// Fetches a message from the guild's persistent message log.
+func FetchStoredMessage(guildID, messageID string) (*discordgo.Message, error) {
+
+	data, err := redis.Bytes(p.Do("GET", messageKey(guildID, messageID)))
+	if err != nil {
+		return nil, err
+	}
+
+	var m discordgo.Message
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// DeleteStoredMessage :
+// Removes a message from the guild's persistent message log.
+func DeleteStoredMessage(guildID, messageID string) {
+
+	_, err := p.Do("DEL", messageKey(guildID, messageID))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	_, err = p.Do("ZREM", messageIndexKey(guildID), messageID)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// PurgeStoredMessages :
+// Removes every message in the guild's persistent message log.
+func PurgeStoredMessages(guildID string) {
+
+	ids, err := redis.Strings(p.Do("ZRANGE", messageIndexKey(guildID), 0, -1))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, v := range ids {
+		p.Do("DEL", messageKey(guildID, v))
+	}
+
+	p.Do("DEL", messageIndexKey(guildID))
+}
+
+// FetchLoggedMessage :
+// Fetches a message from the temp cache, falling back to the persistent message log.
+func FetchLoggedMessage(guildID, messageID string) (*discordgo.Message, bool) {
+
+	if msg, found := c.Get(messageID); found {
+		return msg.(*discordgo.Message), true
+	}
+
+	if guildID == "" {
+		return nil, false
+	}
+
+	m, err := FetchStoredMessage(guildID, messageID)
+	if err != nil {
+		if err != redis.ErrNil {
+			log.Println(err)
+		}
+		return nil, false
+	}
+
+	return m, true
+}
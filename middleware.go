@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+/**
+ * middleware.go
+ * Chase Weaver
+ *
+ * This package handles the middleware chain commands run through, such as panic recovery,
+ * checks, cooldowns, argument parsing and logging.
+ */
+
+// Middleware runs around a command. Calling next continues down the chain to the command, code
+// after next runs once the command returns, and returning without calling next stops the command.
+type Middleware func(ctx Context, next func(Context))
+
+var middleware []Middleware
+var cooldownMu sync.Mutex
+
+func init() {
+	Use(
+		RecoverCommand,
+		CheckCommand,
+		CheckBlacklist,
+		ParseCommandArguments,
+		CheckCooldown,
+		LogCommand,
+		TypingIndicator,
+	)
+}
+
+// Use :
+// Adds middleware to the end of the chain.
+func Use(m ...Middleware) {
+	middleware = append(middleware, m...)
+}
+
+// Dispatch :
+// Runs a command through the middleware chain.
+func Dispatch(ctx Context) {
+	handler := RunCommand
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		m, next := middleware[i], handler
+		handler = func(ctx Context) {
+			m(ctx, next)
+		}
+	}
+
+	handler(ctx)
+}
+
+// RunCommand :
+// Calls the func of a command, or lists the subcommands of a group without one.
+func RunCommand(ctx Context) {
+
+	if ctx.Command.Func == nil {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | Please choose a subcommand.\nUsage: `%s%s %s`", ctx.Prefix, ctx.Command.Path(), ctx.Command.UsageString()))
+		return
+	}
+
+	ctx.Command.Func(ctx)
+}
+
+// CheckCommand :
+// Stops commands that fail their checks or cannot be ran in the channel type.
+func CheckCommand(ctx Context, next func(Context)) {
+
+	// Checks if the config for the command passes all checks and is part of a text channel in a guild
+	if ctx.Channel.Type == discordgo.ChannelTypeGuildText && !CommandIsValid(ctx) {
+		return
+	}
+
+	// Checks if the command can be ran in a DM or not
+	if ctx.Channel.Type == discordgo.ChannelTypeDM && !Contains(ctx.Command.RunIn, "DM") {
+		return
+	}
+
+	next(ctx)
+}
+
+// CheckBlacklist :
+// Ignores commands from blacklisted users and in blacklisted channels. The bot owner and the
+// guild owner are never ignored.
+func CheckBlacklist(ctx Context, next func(Context)) {
+
+	if ctx.Guild == nil || ctx.Event.Author.ID == conf.OwnerID || ctx.Event.Author.ID == ctx.Guild.OwnerID {
+		next(ctx)
+		return
+	}
+
+	g := ctx.Settings
+
+	for _, v := range g.BlacklistedUsers {
+		if v.ID == ctx.Event.Author.ID {
+			return
+		}
+	}
+
+	for _, v := range g.BlacklistedChannels {
+		if v.ID == ctx.Channel.ID {
+			return
+		}
+	}
+
+	next(ctx)
+}
+
+// CheckCooldown :
+// Stops users from running a command again before its cooldown (in seconds) is up. The bot
+// owner has no cooldowns. Runs after the arguments parse, so invalid uses start no cooldown.
+func CheckCooldown(ctx Context, next func(Context)) {
+
+	if ctx.Command.Cooldown == 0 || ctx.Event.Author.ID == conf.OwnerID {
+		next(ctx)
+		return
+	}
+
+	now := int(time.Now().Unix())
+	name := ctx.Command.Path()
+
+	cooldownMu.Lock()
+
+	// Drops expired cooldowns while looking for the command's
+	var active []Cooldown
+	remaining := 0
+	for _, v := range cooldown[ctx.Event.Author.ID] {
+		if v.Time <= now {
+			continue
+		}
+
+		if v.Name == name {
+			remaining = v.Time - now
+		}
+		active = append(active, v)
+	}
+
+	if remaining == 0 {
+		active = append(active, Cooldown{Name: name, Time: now + ctx.Command.Cooldown})
+	}
+
+	if len(active) == 0 {
+		delete(cooldown, ctx.Event.Author.ID)
+	} else {
+		cooldown[ctx.Event.Author.ID] = active
+	}
+
+	cooldownMu.Unlock()
+
+	if remaining != 0 {
+		msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | You can use `%s` again in %s.", name, time.Duration(remaining)*time.Second))
+
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		DeleteMessageWithTime(ctx, msg.ID, 5000)
+		return
+	}
+
+	next(ctx)
+}
+
+// ParseCommandArguments :
+// Takes the declared flags out of the arguments and parses the declared parameters, replying
+// with the usage if they are invalid.
+func ParseCommandArguments(ctx Context, next func(Context)) {
+	var err error
+
+	ctx.Flags = make(map[string]string)
+
+	if len(ctx.Command.Flags) != 0 {
+		ctx.tokens, ctx.Flags, err = ParseFlags(ctx.Command.Flags, ctx.tokens)
+
+		if err != nil {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | %s\nUsage: `%s%s %s`", err, ctx.Prefix, ctx.Command.Path(), ctx.Command.UsageString()))
+			return
+		}
+	}
+
+	ctx.Args = nil
+	for _, t := range ctx.tokens {
+		ctx.Args = append(ctx.Args, t.Value)
+	}
+
+	// Parses typed arguments for commands that declare parameters
+	if len(ctx.Command.Params) != 0 {
+		ctx.Arguments, err = ParseArguments(ctx, ctx.Command.Params)
+
+		if err != nil {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | %s\nUsage: `%s%s %s`", err, ctx.Prefix, ctx.Command.Path(), ctx.Command.UsageString()))
+			return
+		}
+	}
+
+	next(ctx)
+}
+
+// LogCommand :
+// Logs commands to the console.
+func LogCommand(ctx Context, next func(Context)) {
+	LogCommands(ctx)
+	next(ctx)
+}
+
+// TypingIndicator :
+// Types in the channel while the command runs.
+func TypingIndicator(ctx Context, next func(Context)) {
+	err := ctx.Session.ChannelTyping(ctx.Channel.ID)
+
+	if err != nil {
+		log.Println(err)
+	}
+
+	next(ctx)
+}
@@ -1,14 +1,12 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"sort"
 	"strings"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/gomodule/redigo/redis"
 )
 
 /**
@@ -30,7 +28,6 @@ func init() {
 		RunIn:           []string{"Text"},
 		Aliases:         []string{},
 		UserPermissions: []string{},
-		ArgsDelim:       " ",
 		Usage:           []string{"[Command Name]"},
 		Description:     "Displays a helpful help menu for all commands, or just one.",
 	})
@@ -46,7 +43,6 @@ func init() {
 		RunIn:           []string{"Text", "DM"},
 		Aliases:         []string{"pfp", "icon"},
 		UserPermissions: []string{},
-		ArgsDelim:       " ",
 		Usage:           []string{"[@Member(s)|ID(s)|Name(s)]"},
 		Description:     "Fetches the avatar for the requested member, or command author.",
 	})
@@ -56,6 +52,13 @@ func init() {
 // Returns help per all-basis or per command-basis
 func Help(ctx Context) {
 
+	// Get guild information from database
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
 	if len(ctx.Args) == 0 {
 
 		help := "== Helpful Help Menu ==\n\n"
@@ -63,8 +66,12 @@ func Help(ctx Context) {
 
 		// Fetch commands the user has permissions to run
 		for _, v := range commands {
+			if Contains(g.DisabledCommands, v.Name) {
+				continue
+			}
+
 			if len(v.UserPermissions) == 0 {
-				tmp = append(tmp, fmt.Sprintf("%-10s::  %s", v.Name, v.Description))
+				tmp = append(tmp, fmt.Sprintf("%-10s::  %s", g.CommandPath(v), v.Description))
 			} else {
 				isValid := false
 				for _, k := range v.UserPermissions {
@@ -73,7 +80,7 @@ func Help(ctx Context) {
 					}
 				}
 				if isValid {
-					tmp = append(tmp, fmt.Sprintf("%-10s::  %s", v.Name, v.Description))
+					tmp = append(tmp, fmt.Sprintf("%-10s::  %s", g.CommandPath(v), v.Description))
 				}
 			}
 		}
@@ -117,12 +124,12 @@ func Help(ctx Context) {
 		}
 	} else {
 
-		// Fetch command from message args
-		cmd := FetchCommand(strings.Join(ctx.Args, ctx.Command.ArgsDelim))
+		// Fetch command from message args, following its subcommands
+		cmd, _ := FetchSubcommand(FetchCommand(g, ctx.Args[0]), ctx.tokens[1:])
 
 		// Return if the args cannot find the requested command
 		if cmd.isEmpty() {
-			msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("`%s` is not a valid command!", strings.Join(ctx.Args, ctx.Command.ArgsDelim)))
+			msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("`%s` is not a valid command!", ctx.RawArgs(0)))
 
 			if err != nil {
 				log.Println(err)
@@ -148,23 +155,10 @@ func Help(ctx Context) {
 			return
 		}
 
-		// Get guild information from database
-		data, err := redis.Bytes(p.Do("GET", ctx.Guild.ID))
-		if err != nil {
-			log.Println(err)
-		}
-
-		var g Guild
-		err = json.Unmarshal(data, &g)
-
-		if err != nil {
-			log.Println(err)
-		}
-
 		runIn := strings.Join(cmd.RunIn, ", ")
 		aliases := strings.Join(cmd.Aliases, ", ")
 		permissions := strings.Join(cmd.UserPermissions, ", ")
-		usage := g.GuildPrefix + cmd.Name + " " + strings.Join(cmd.Usage, cmd.ArgsDelim)
+		usage := g.GuildPrefix + g.CommandPath(cmd) + " " + cmd.UsageString()
 
 		if len(cmd.Aliases) == 0 {
 			aliases = "N/A"
@@ -174,7 +168,15 @@ func Help(ctx Context) {
 			permissions = "N/A"
 		}
 
-		help := fmt.Sprintf("== %s Help ==\n\nName        :: %s\nRuns In     :: %s\nAliases     :: %s\nPermissions :: %s\nUsage       :: %s\nDescription :: %s", cmd.Name, cmd.Name, runIn, aliases, permissions, usage, cmd.Description)
+		help := fmt.Sprintf("== %s Help ==\n\nName        :: %s\nRuns In     :: %s\nAliases     :: %s\nPermissions :: %s\nUsage       :: %s\nDescription :: %s", g.CommandPath(cmd), g.CommandPath(cmd), runIn, aliases, permissions, usage, cmd.Description)
+
+		// List subcommands of groups
+		if len(cmd.Subcommands) != 0 {
+			help += "\n\n== Subcommands ==\n\n"
+			for _, v := range cmd.Subcommands {
+				help += fmt.Sprintf("%-10s::  %s\n", v.Name, v.Description)
+			}
+		}
 
 		// Creates DM channel between bot and message author
 		channel, err := ctx.Session.UserChannelCreate(ctx.Event.Author.ID)
@@ -225,7 +227,7 @@ func Avatar(ctx Context) {
 	}
 
 	// Fetch users from message content
-	members := FetchMessageContentUsers(ctx, strings.Join(ctx.Args, ctx.Command.ArgsDelim))
+	members := FetchMessageContentUsers(ctx, ctx.RawArgs(0))
 
 	// Returns every mentioned member's avatar as seperate messages
 	for _, m := range members {
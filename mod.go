@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,9 +31,11 @@ func init() {
 		RunIn:           []string{"Text"},
 		Aliases:         []string{},
 		UserPermissions: []string{"Bot Owner", "Kick Members"},
-		ArgsDelim:       " ",
-		Usage:           []string{"<@Member(s)|ID(s)|Name(s)>"},
-		Description:     "Warns a member.",
+		Params: []Param{
+			{Name: "members", Type: ArgMember, Variadic: true},
+			{Name: "reason", Type: ArgRest, Optional: true},
+		},
+		Description: "Warns a member.",
 	})
 
 	RegisterNewCommand(Command{
@@ -46,9 +49,11 @@ func init() {
 		RunIn:           []string{"Text"},
 		Aliases:         []string{},
 		UserPermissions: []string{"Bot Owner", "Kick Members"},
-		ArgsDelim:       " ",
-		Usage:           []string{"<@Member(s)|ID(s)|Name#xxxx(s)>"},
-		Description:     "Kicks a member from the guild.",
+		Params: []Param{
+			{Name: "members", Type: ArgMember, Variadic: true},
+			{Name: "reason", Type: ArgRest, Optional: true},
+		},
+		Description: "Kicks a member from the guild.",
 	})
 
 	RegisterNewCommand(Command{
@@ -62,9 +67,14 @@ func init() {
 		RunIn:           []string{"Text"},
 		Aliases:         []string{},
 		UserPermissions: []string{"Bot Owner", "Ban Members"},
-		ArgsDelim:       " ",
-		Usage:           []string{"<Member(s)|ID(s)|Name#xxxx(s)>"},
-		Description:     "Bans a member from the guild.",
+		Params: []Param{
+			{Name: "members", Type: ArgUser, Variadic: true},
+			{Name: "reason", Type: ArgRest, Optional: true},
+		},
+		Flags: []Flag{
+			{Name: "days", Short: "d", Value: "0-7"},
+		},
+		Description: "Bans a member from the guild, deleting their messages from the last few days (optional).",
 	})
 
 	RegisterNewCommand(Command{
@@ -78,7 +88,6 @@ func init() {
 		RunIn:           []string{"Text"},
 		Aliases:         []string{"lockdown"},
 		UserPermissions: []string{"Bot Owner", "Manage Channels"},
-		ArgsDelim:       "",
 		Usage:           []string{},
 		Description:     "Locks a channel (prevents SEND_MESSAGES) for the default @everyone permission.",
 	})
@@ -94,7 +103,6 @@ func init() {
 		RunIn:           []string{"Text"},
 		Aliases:         []string{},
 		UserPermissions: []string{"Bot Owner", "Manage Channels"},
-		ArgsDelim:       "",
 		Usage:           []string{},
 		Description:     "Unlocks a channel (grants SEND_MESSAGES) for the default @everyone permission.",
 	})
@@ -110,9 +118,12 @@ func init() {
 		RunIn:           []string{"Text"},
 		Aliases:         []string{},
 		UserPermissions: []string{"Bot Owner", "Kick Members"},
-		ArgsDelim:       " ",
-		Usage:           []string{"<@Member(s)|ID(s)|Name#xxxx(s)>", "[1h30m|2h|30m|etc]", "[reason]"},
-		Description:     "Mutes a user with a set role with a reason (optional) and a time (optional).",
+		Params: []Param{
+			{Name: "members", Type: ArgMember, Variadic: true},
+			{Name: "duration", Type: ArgDuration, Optional: true},
+			{Name: "reason", Type: ArgRest, Optional: true},
+		},
+		Description: "Mutes a user with a set role with a reason (optional) and a time (optional).",
 	})
 
 	RegisterNewCommand(Command{
@@ -126,14 +137,19 @@ func init() {
 		RunIn:           []string{"Text"},
 		Aliases:         []string{},
 		UserPermissions: []string{"Bot Owner", "Kick Members"},
-		ArgsDelim:       " ",
-		Usage:           []string{"<@Member(s)|ID(s)|Name#xxxx(s)>", "[warnings|mutes|kicks|bans|nicknames|usernames]"},
-		Description:     "Checks the warnings, mutes, kicks, bans, nicknames, and usernames of a mentioned user.",
+		Params:          []Param{usersParam},
+		Subcommands: []Command{
+			{Name: "warnings", Func: CheckRecords, Params: []Param{usersParam}, Description: "Lists the warnings of members."},
+			{Name: "kicks", Func: CheckRecords, Params: []Param{usersParam}, Description: "Lists the kicks of members."},
+			{Name: "bans", Func: CheckRecords, Params: []Param{usersParam}, Description: "Lists the bans of members."},
+			{Name: "nicknames", Func: CheckRecords, Params: []Param{usersParam}, Description: "Lists the nicknames of members."},
+			{Name: "usernames", Func: CheckRecords, Params: []Param{usersParam}, Description: "Lists the usernames of members."},
+		},
+		Description: "Checks the warnings, mutes, kicks, bans, nicknames, and usernames of a mentioned user.",
 	})
 
 	RegisterNewCommand(Command{
 		Name:            "clear",
-		Func:            Clear,
 		Enabled:         true,
 		NSFWOnly:        false,
 		IgnoreSelf:      true,
@@ -142,18 +158,86 @@ func init() {
 		RunIn:           []string{"Text"},
 		Aliases:         []string{"reset"},
 		UserPermissions: []string{"Bot Owner", "Administrator", "Ban Members", "Kick Members"},
-		ArgsDelim:       " ",
-		Usage:           []string{"<@Member|ID|Name#xxxx> <warnings|mutes|kicks|bans|usernames|nicknames|all>"},
-		Description:     "Clears a guild member's recorded data.",
+		Subcommands: []Command{
+			{Name: "warnings", Func: ClearRecords, Params: []Param{userParam}, Description: "Clears the warnings of a member."},
+			{Name: "mutes", Func: ClearRecords, Params: []Param{userParam}, Description: "Clears the mutes of a member."},
+			{Name: "kicks", Func: ClearRecords, Params: []Param{userParam}, Description: "Clears the kicks of a member."},
+			{Name: "bans", Func: ClearRecords, Params: []Param{userParam}, Description: "Clears the bans of a member."},
+			{Name: "nicknames", Func: ClearRecords, Params: []Param{userParam}, Description: "Clears the nicknames of a member."},
+			{Name: "usernames", Func: ClearRecords, Params: []Param{userParam}, Description: "Clears the usernames of a member."},
+			{Name: "all", Func: ClearRecords, Params: []Param{userParam}, Description: "Clears all recorded data of a member."},
+		},
+		Description: "Clears a guild member's recorded data.",
 	})
 }
 
+// Parameters shared by the check and clear subcommands. They take users rather than members, so
+// the records of members who left or were banned can still be checked and cleared.
+var (
+	usersParam = Param{Name: "users", Type: ArgUser, Variadic: true}
+	userParam  = Param{Name: "user", Type: ArgUser}
+)
+
+// recordType of the moderation records kept per guild user
+type recordType struct {
+	Title  string
+	Count  func(GuildUser) int
+	Format func(GuildUser) string
+	Clear  func(*GuildUser)
+}
+
+// recordTypes maps the check and clear subcommands to the records they list and reset
+var recordTypes = map[string]recordType{
+	"warnings": {
+		Title:  "Warning Stats",
+		Count:  func(u GuildUser) int { return len(u.Warnings) },
+		Format: func(u GuildUser) string { return FormatWarnings(u.Warnings) },
+		Clear:  func(u *GuildUser) { u.Warnings = make(map[int64]Warnings) },
+	},
+	"mutes": {
+		Title: "Mute Stats",
+		Count: func(u GuildUser) int { return len(u.Mutes) },
+		Clear: func(u *GuildUser) { u.Mutes = make(map[int64]Mutes) },
+	},
+	"kicks": {
+		Title:  "Kick Stats",
+		Count:  func(u GuildUser) int { return len(u.Kicks) },
+		Format: func(u GuildUser) string { return FormatKicks(u.Kicks) },
+		Clear:  func(u *GuildUser) { u.Kicks = make(map[int64]Kicks) },
+	},
+	"bans": {
+		Title:  "Ban Stats",
+		Count:  func(u GuildUser) int { return len(u.Bans) },
+		Format: func(u GuildUser) string { return FormatBans(u.Bans) },
+		Clear:  func(u *GuildUser) { u.Bans = make(map[int64]Bans) },
+	},
+	"nicknames": {
+		Title:  "Nickname Stats",
+		Count:  func(u GuildUser) int { return len(u.Nicknames) },
+		Format: func(u GuildUser) string { return FormatNicknames(u.Nicknames) },
+		Clear:  func(u *GuildUser) { u.Nicknames = make(map[int64]Nicknames) },
+	},
+	"usernames": {
+		Title:  "Username Stats",
+		Count:  func(u GuildUser) int { return len(u.Usernames) },
+		Format: func(u GuildUser) string { return FormatUsernames(u.Usernames) },
+		Clear: func(u *GuildUser) {
+			u.Usernames = make(map[int64]Usernames)
+			u.Usernames[MakeTimestamp()] = Usernames{
+				Username:      u.User.Username,
+				Discriminator: u.User.Discriminator,
+				Time:          time.Now(),
+			}
+		},
+	},
+}
+
 // Warn :
 // Warn a user by ID / Name#xxxx / Mention, logs it to the redis database.
 func Warn(ctx Context) {
 
-	// Fetch users from message content, returns list of members and the remaining string with the member removed
-	members, reason := FetchMessageContentUsersString(ctx, strings.Join(ctx.Args, ctx.Command.ArgsDelim))
+	// Members and reason parsed from the command arguments
+	members, reason := ctx.Arguments.Users("members"), ctx.Arguments.String("reason")
 
 	// Returns if a user cannot be found in the message, deletes command message, then deletes delayed response
 	if len(members) == 0 {
@@ -185,6 +269,11 @@ func Warn(ctx Context) {
 	// Warns all members found within the message, logs warning to redis database
 	for _, member := range members {
 
+		// Stops early if the command times out or the bot shuts down
+		if ctx.Err() != nil {
+			return
+		}
+
 		// Prevent someone from warning the bot
 		if member.ID == ctx.Session.State.User.ID {
 			msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I will not warn myself!")
@@ -210,8 +299,8 @@ func Warn(ctx Context) {
 		LogWarning(ctx, member, reason)
 
 		// Send logs to Guild Moderation Channel
-		if guildErr != nil && g.ModerationLogsChannel != nil {
-			ctx.Session.ChannelMessageSendEmbed(g.ModerationLogsChannel.ID,
+		if guildErr == nil {
+			SendGuildLogEmbed(ctx.Session, g, LogEventModeration,
 				NewEmbed().
 					SetTitle("Member Warned").
 					SetColor(warningColor).
@@ -241,8 +330,8 @@ func Warn(ctx Context) {
 // Kicks a user by ID / Name#xxxx / Mention, logs it to the redis database.
 func Kick(ctx Context) {
 
-	// Fetch users from message content, returns list of members and the remaining string with the member removed
-	members, reason := FetchMessageContentUsersString(ctx, strings.Join(ctx.Args, ctx.Command.ArgsDelim))
+	// Members and reason parsed from the command arguments
+	members, reason := ctx.Arguments.Users("members"), ctx.Arguments.String("reason")
 
 	// Returns if a user cannot be found in the message, deletes command message, then deletes delayed response
 	if len(members) == 0 {
@@ -275,6 +364,11 @@ func Kick(ctx Context) {
 	// Kicks all members found within the message, logs warning to redis database
 	for _, member := range members {
 
+		// Stops early if the command times out or the bot shuts down
+		if ctx.Err() != nil {
+			return
+		}
+
 		// Prevent someone from kicking the bot
 		if member.ID == ctx.Session.State.User.ID {
 			msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I will not kick myself!")
@@ -300,8 +394,8 @@ func Kick(ctx Context) {
 		LogKick(ctx, member, reason)
 
 		// Send logs to Guild Moderation Channel
-		if guildErr != nil && g.ModerationLogsChannel != nil {
-			ctx.Session.ChannelMessageSendEmbed(g.ModerationLogsChannel.ID,
+		if guildErr == nil {
+			SendGuildLogEmbed(ctx.Session, g, LogEventModeration,
 				NewEmbed().
 					SetTitle("Member Kicked").
 					SetColor(kickColor).
@@ -345,8 +439,8 @@ func Kick(ctx Context) {
 // Bans a user by ID / Name#xxxx / Mention, logs it to the redis database.
 func Ban(ctx Context) {
 
-	// Fetch users from message content, returns list of members and the remaining string with the member removed
-	members, reason := FetchMessageContentUsersString(ctx, strings.Join(ctx.Args, ctx.Command.ArgsDelim))
+	// Members and reason parsed from the command arguments
+	members, reason := ctx.Arguments.Users("members"), ctx.Arguments.String("reason")
 
 	// Returns if a user cannot be found in the message, deletes command message, then deletes delayed response
 	if len(members) == 0 {
@@ -361,6 +455,19 @@ func Ban(ctx Context) {
 		return
 	}
 
+	// Days of messages to delete, set with --days
+	days := 0
+	if ctx.HasFlag("days") {
+		n, err := strconv.Atoi(ctx.Flags["days"])
+
+		if err != nil || n < 0 || n > 7 {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | `--days` must be a number from 0 to 7.")
+			return
+		}
+
+		days = n
+	}
+
 	// Delete command message
 	DeleteMessageWithTime(ctx, ctx.Event.Message.ID, 0)
 
@@ -378,6 +485,11 @@ func Ban(ctx Context) {
 	// Bans all members found within the message, logs warning to redis database
 	for _, member := range members {
 
+		// Stops early if the command times out or the bot shuts down
+		if ctx.Err() != nil {
+			return
+		}
+
 		// Prevent someone from banning the bot
 		if member.ID == ctx.Session.State.User.ID {
 			msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I will not ban myself!")
@@ -403,8 +515,8 @@ func Ban(ctx Context) {
 		LogBan(ctx, member, reason)
 
 		// Send logs to Guild Moderation Channel
-		if guildErr != nil && g.ModerationLogsChannel != nil {
-			ctx.Session.ChannelMessageSendEmbed(g.ModerationLogsChannel.ID,
+		if guildErr == nil {
+			SendGuildLogEmbed(ctx.Session, g, LogEventModeration,
 				NewEmbed().
 					SetTitle("Member Banned").
 					SetColor(banColor).
@@ -428,8 +540,8 @@ func Ban(ctx Context) {
 			ctx.Session.ChannelMessageSend(channel.ID, fmt.Sprintf("You have been banned by `%s` %s.", author, tr))
 		}
 
-		// Bans the guild member with given reason, deletes 0 messages
-		err = ctx.Session.GuildBanCreateWithReason(ctx.Guild.ID, member.ID, reason, 0)
+		// Bans the guild member with given reason, deletes their messages from the given days
+		err = ctx.Session.GuildBanCreateWithReason(ctx.Guild.ID, member.ID, reason, days)
 
 		if err != nil {
 			msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I cannot ban this user!")
@@ -554,7 +666,7 @@ func Mute(ctx Context) {
 
 	// Check if the guild role is set
 	if g.MutedRole == nil {
-		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | You do not have a muted role set up! Please configure one using `%sset muted role%s<@Role|Name|ID>`", g.GuildPrefix, commands["set"].ArgsDelim))
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | You do not have a muted role set up! Please configure one using `%sconfig set muted role <@Role|Name|ID>`", g.GuildPrefix))
 		return
 	}
 
@@ -567,9 +679,8 @@ func Mute(ctx Context) {
 		return
 	}
 
-	// Fetch users from message content, returns list of members and the remaining string with the member removed
-	members, reason := FetchMessageContentUsersString(ctx, strings.Join(ctx.Args, ctx.Command.ArgsDelim))
-	length, _ := time.ParseDuration(reason)
+	// Members, mute length and reason parsed from the command arguments
+	members, length, reason := ctx.Arguments.Users("members"), ctx.Arguments.Duration("duration"), ctx.Arguments.String("reason")
 
 	// Retuns if a user cannot be found in the message, deletes delayed response
 	if len(members) == 0 {
@@ -591,6 +702,11 @@ func Mute(ctx Context) {
 	// Bans all members found within the message, logs warning to redis database
 	for _, member := range members {
 
+		// Stops early if the command times out or the bot shuts down
+		if ctx.Err() != nil {
+			return
+		}
+
 		// Prevent someone from muting the bot
 		if member.ID == ctx.Session.State.User.ID {
 			msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I will not mute myself!")
@@ -643,8 +759,8 @@ func Mute(ctx Context) {
 		// Logs mutes to redis database
 		LogMute(ctx, member, reason, length)
 
-		if g.ModerationLogsChannel != nil {
-			ctx.Session.ChannelMessageSendEmbed(g.ModerationLogsChannel.ID,
+		if g.LogChannelFor(LogEventModeration) != nil {
+			SendGuildLogEmbed(ctx.Session, g, LogEventModeration,
 				NewEmbed().
 					SetTitle("Member Mute").
 					SetColor(muteColor).
@@ -687,7 +803,7 @@ func Unmute(ctx Context) {
 
 	// Check if the guild role is set
 	if g.MutedRole == nil {
-		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | You do not have a muted role set up! Please configure one using `%sset muted role%s<@Role|Name|ID>`", g.GuildPrefix, commands["set"].ArgsDelim))
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | You do not have a muted role set up! Please configure one using `%sconfig set muted role <@Role|Name|ID>`", g.GuildPrefix))
 		return
 	}
 
@@ -701,7 +817,7 @@ func Unmute(ctx Context) {
 	}
 
 	// Fetch users from message content, returns list of members and the remaining string with the member removed
-	members, reason := FetchMessageContentUsersString(ctx, strings.Join(ctx.Args, ctx.Command.ArgsDelim))
+	members, reason := FetchMessageContentUsersString(ctx, ctx.RawArgs(0))
 
 	// Retuns if a user cannot be found in the message, deletes delayed response
 	if len(members) == 0 {
@@ -723,6 +839,11 @@ func Unmute(ctx Context) {
 	// Bans all members found within the message, logs warning to redis database
 	for _, member := range members {
 
+		// Stops early if the command times out or the bot shuts down
+		if ctx.Err() != nil {
+			return
+		}
+
 		// Target username
 		target := member.Username + "#" + member.Discriminator
 
@@ -749,8 +870,8 @@ func Unmute(ctx Context) {
 			break
 		}
 
-		if g.ModerationLogsChannel != nil {
-			ctx.Session.ChannelMessageSendEmbed(g.ModerationLogsChannel.ID,
+		if g.LogChannelFor(LogEventModeration) != nil {
+			SendGuildLogEmbed(ctx.Session, g, LogEventModeration,
 				NewEmbed().
 					SetTitle("Member Unmute").
 					SetColor(unmuteColor).
@@ -773,28 +894,11 @@ func Unmute(ctx Context) {
 }
 
 // Check :
-// Check the user's warnings, mutes, kicks, bans, nicknames, and usernames from the redis database.
+// Shows the totals of a member's warnings, mutes, kicks, bans, nicknames, and usernames from the redis database.
 func Check(ctx Context) {
 
-	// Fetch users from message content
-	members, checkType := FetchMessageContentUsersString(ctx, strings.Join(ctx.Args, ctx.Command.ArgsDelim))
-
-	// Type of check to look for
-	if len(checkType) > 1 {
-		checkType = strings.ToUpper(checkType)[1:]
-	}
-
-	// Returns if a user cannot be found in the message, deletes delayed response
-	if len(members) == 0 {
-		msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I cannot find that user!")
-
-		if err != nil {
-			return
-		}
-
-		DeleteMessageWithTime(ctx, msg.ID, 7500)
-		return
-	}
+	// Members parsed from the command arguments
+	members := ctx.Arguments.Users("users")
 
 	// Fetch guild information
 	g, err := UnpackGuildStruct(ctx.Guild.ID)
@@ -803,238 +907,107 @@ func Check(ctx Context) {
 		return
 	}
 
-	switch checkType {
-	case "WARNINGS":
-		for _, member := range members {
-			if _, ok := g.GuildUser[member.ID]; !ok {
-				msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I do not have any logs for that user!")
-
-				if err != nil {
-					return
-				}
-
-				DeleteMessageWithTime(ctx, msg.ID, 7500)
-				return
-			}
-
-			if len(g.GuildUser[member.ID].Warnings) == 0 {
-				msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | No warnings found!")
-				if err != nil {
-					log.Println(err)
-					return
-				}
-				DeleteMessageWithTime(ctx, msg.ID, 5000)
-				return
-			}
+	for _, member := range members {
 
-			str := FormatWarnings(g.GuildUser[member.ID].Warnings)
-			ctx.Session.ChannelMessageSendEmbed(ctx.Channel.ID,
-				NewEmbed().
-					SetTitle(fmt.Sprintf("Warning Stats [%d]", len(g.GuildUser[member.ID].Warnings))).
-					SetColor(warningColor).
-					SetAuthor(fmt.Sprintf("%s#%s / %s", member.Username, member.Discriminator, member.ID), g.GuildUser[member.ID].User.AvatarURL("256"), g.GuildUser[member.ID].User.AvatarURL("2048")).
-					SetDescription(str).
-					SetTimestamp(time.Now().Format(time.RFC3339)).MessageEmbed)
+		// Stops early if the command times out or the bot shuts down
+		if ctx.Err() != nil {
+			return
 		}
 
-	case "KICKS":
-		for _, member := range members {
-			if _, ok := g.GuildUser[member.ID]; !ok {
-				msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I do not have any logs for that user!")
-
-				if err != nil {
-					log.Println(err)
-					return
-				}
-
-				DeleteMessageWithTime(ctx, msg.ID, 7500)
-				return
-			}
+		if _, ok := g.GuildUser[member.ID]; !ok {
+			msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I do not have any logs for that user!")
 
-			if len(g.GuildUser[member.ID].Kicks) == 0 {
-				msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | No kicks found!")
-				if err != nil {
-					log.Println(err)
-					return
-				}
-				DeleteMessageWithTime(ctx, msg.ID, 5000)
+			if err != nil {
+				log.Println(err)
 				return
 			}
 
-			str := FormatKicks(g.GuildUser[member.ID].Kicks)
-			ctx.Session.ChannelMessageSendEmbed(ctx.Channel.ID,
-				NewEmbed().
-					SetTitle(fmt.Sprintf("Kick Stats [%d]", len(g.GuildUser[member.ID].Kicks))).
-					SetColor(warningColor).
-					SetAuthor(fmt.Sprintf("%s#%s / %s", member.Username, member.Discriminator, member.ID), g.GuildUser[member.ID].User.AvatarURL("256"), g.GuildUser[member.ID].User.AvatarURL("2048")).
-					SetDescription(str).
-					SetTimestamp(time.Now().Format(time.RFC3339)).MessageEmbed)
+			DeleteMessageWithTime(ctx, msg.ID, 7500)
+			return
 		}
 
-	case "BANS":
-		for _, member := range members {
-			if _, ok := g.GuildUser[member.ID]; !ok {
-				msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I do not have any logs for that user!")
-
-				if err != nil {
-					log.Println(err)
-					return
-				}
+		_, err := ctx.Session.ChannelMessageSendEmbed(ctx.Channel.ID,
+			NewEmbed().
+				SetTitle(fmt.Sprintf("Run `%scheck <warnings|kicks|bans|usernames|nicknames> <@member|ID|Name#xxxx>` for a complete list of information.", g.GuildPrefix)).
+				SetColor(RandomInt(0, 16777215)).
+				SetAuthor(fmt.Sprintf("%s#%s / %s", member.Username, member.Discriminator, member.ID),
+					g.GuildUser[member.ID].User.AvatarURL("256"), g.GuildUser[member.ID].User.AvatarURL("2048")).
+				SetThumbnail(member.AvatarURL("2048")).
+				AddField("❯ Total Warnings", fmt.Sprintf("%d", len(g.GuildUser[member.ID].Warnings))).
+				AddField("❯ Total Mutes", fmt.Sprintf("%d", len(g.GuildUser[member.ID].Mutes))).
+				AddField("❯ Total Kicks", fmt.Sprintf("%d", len(g.GuildUser[member.ID].Kicks))).
+				AddField("❯ Total Bans", fmt.Sprintf("%d", len(g.GuildUser[member.ID].Bans))).
+				AddField("❯ Total Nicknames", fmt.Sprintf("%d", len(g.GuildUser[member.ID].Nicknames))).
+				AddField("❯ Total Usernames", fmt.Sprintf("%d", len(g.GuildUser[member.ID].Usernames))).
+				SetTimestamp(time.Now().Format(time.RFC3339)).MessageEmbed)
 
-				DeleteMessageWithTime(ctx, msg.ID, 7500)
-				return
-			}
-
-			if len(g.GuildUser[member.ID].Bans) == 0 {
-				msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | No bans found!")
-				if err != nil {
-					log.Println(err)
-					return
-				}
-				DeleteMessageWithTime(ctx, msg.ID, 5000)
-				return
-			}
-
-			str := FormatBans(g.GuildUser[member.ID].Bans)
-			ctx.Session.ChannelMessageSendEmbed(ctx.Channel.ID,
-				NewEmbed().
-					SetTitle(fmt.Sprintf("Ban Stats [%d]", len(g.GuildUser[member.ID].Bans))).
-					SetColor(warningColor).
-					SetAuthor(fmt.Sprintf("%s#%s / %s", member.Username, member.Discriminator, member.ID), g.GuildUser[member.ID].User.AvatarURL("256"), g.GuildUser[member.ID].User.AvatarURL("2048")).
-					SetDescription(str).
-					SetTimestamp(time.Now().Format(time.RFC3339)).MessageEmbed)
+		if err != nil {
+			log.Println(err)
+			return
 		}
-	case "NICKNAMES":
-		for _, member := range members {
-			if _, ok := g.GuildUser[member.ID]; !ok {
-				msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I do not have any logs for that user!")
-
-				if err != nil {
-					log.Println(err)
-					return
-				}
-
-				DeleteMessageWithTime(ctx, msg.ID, 7500)
-				return
-			}
-
-			if len(g.GuildUser[member.ID].Nicknames) == 0 {
-				msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | No nicknames found!")
-				if err != nil {
-					log.Println(err)
-					return
-				}
-				DeleteMessageWithTime(ctx, msg.ID, 5000)
-				return
-			}
+	}
+}
 
-			str := FormatNicknames(g.GuildUser[member.ID].Nicknames)
-			ctx.Session.ChannelMessageSendEmbed(ctx.Channel.ID,
-				NewEmbed().
-					SetTitle(fmt.Sprintf("Nickname Stats [%d]", len(g.GuildUser[member.ID].Nicknames))).
-					SetColor(warningColor).
-					SetAuthor(fmt.Sprintf("%s#%s / %s", member.Username, member.Discriminator, member.ID), g.GuildUser[member.ID].User.AvatarURL("256"), g.GuildUser[member.ID].User.AvatarURL("2048")).
-					SetDescription(str).
-					SetTimestamp(time.Now().Format(time.RFC3339)).MessageEmbed)
-		}
-	case "USERNAMES":
-		for _, member := range members {
-			if _, ok := g.GuildUser[member.ID]; !ok {
-				msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I do not have any logs for that user!")
+// CheckRecords :
+// Lists one type of a member's records, named by the check subcommand.
+func CheckRecords(ctx Context) {
 
-				if err != nil {
-					log.Println(err)
-					return
-				}
+	// Members parsed from the command arguments
+	members, rt := ctx.Arguments.Users("users"), recordTypes[ctx.Command.Name]
 
-				DeleteMessageWithTime(ctx, msg.ID, 7500)
-				return
-			}
+	// Fetch guild information
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
 
-			if len(g.GuildUser[member.ID].Usernames) == 0 {
-				msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | No usernames found!")
-				if err != nil {
-					log.Println(err)
-					return
-				}
-				DeleteMessageWithTime(ctx, msg.ID, 5000)
-				return
-			}
+	for _, member := range members {
 
-			str := FormatUsernames(g.GuildUser[member.ID].Usernames)
-			ctx.Session.ChannelMessageSendEmbed(ctx.Channel.ID,
-				NewEmbed().
-					SetTitle(fmt.Sprintf("Username Stats [%d]", len(g.GuildUser[member.ID].Usernames))).
-					SetColor(warningColor).
-					SetAuthor(fmt.Sprintf("%s#%s / %s", member.Username, member.Discriminator, member.ID), g.GuildUser[member.ID].User.AvatarURL("256"), g.GuildUser[member.ID].User.AvatarURL("2048")).
-					SetDescription(str).
-					SetTimestamp(time.Now().Format(time.RFC3339)).MessageEmbed)
+		// Stops early if the command times out or the bot shuts down
+		if ctx.Err() != nil {
+			return
 		}
-	default:
-
-		for _, member := range members {
-			if _, ok := g.GuildUser[member.ID]; !ok {
-				msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I do not have any logs for that user!")
 
-				if err != nil {
-					log.Println(err)
-					return
-				}
+		user, ok := g.GuildUser[member.ID]
+		if !ok {
+			msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I do not have any logs for that user!")
 
-				DeleteMessageWithTime(ctx, msg.ID, 7500)
+			if err != nil {
+				log.Println(err)
 				return
 			}
 
-			_, err := ctx.Session.ChannelMessageSendEmbed(ctx.Channel.ID,
-				NewEmbed().
-					SetTitle(fmt.Sprintf("Run `%scheck <@member|ID|Name#xxxx> [warnings|mutes|kicks|bans|usernames|nicknames]` for a complete list of information.", g.GuildPrefix)).
-					SetColor(RandomInt(0, 16777215)).
-					SetAuthor(fmt.Sprintf("%s#%s / %s", member.Username, member.Discriminator, member.ID),
-						g.GuildUser[member.ID].User.AvatarURL("256"), g.GuildUser[member.ID].User.AvatarURL("2048")).
-					SetThumbnail(member.AvatarURL("2048")).
-					AddField("❯ Total Warnings", fmt.Sprintf("%d", len(g.GuildUser[member.ID].Warnings))).
-					AddField("❯ Total Mutes", fmt.Sprintf("%d", len(g.GuildUser[member.ID].Mutes))).
-					AddField("❯ Total Kicks", fmt.Sprintf("%d", len(g.GuildUser[member.ID].Kicks))).
-					AddField("❯ Total Bans", fmt.Sprintf("%d", len(g.GuildUser[member.ID].Bans))).
-					AddField("❯ Total Nicknames", fmt.Sprintf("%d", len(g.GuildUser[member.ID].Nicknames))).
-					AddField("❯ Total Usernames", fmt.Sprintf("%d", len(g.GuildUser[member.ID].Usernames))).
-					SetTimestamp(time.Now().Format(time.RFC3339)).MessageEmbed)
+			DeleteMessageWithTime(ctx, msg.ID, 7500)
+			return
+		}
 
+		if rt.Count(user) == 0 {
+			msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | No %s found!", ctx.Command.Name))
 			if err != nil {
 				log.Println(err)
 				return
 			}
-
-		}
-	}
-}
-
-// Clear :
-// Clears a GuildUser's recorded information
-func Clear(ctx Context) {
-
-	// Fetch users from message content
-	members, checkType := FetchMessageContentUsersString(ctx, strings.Join(ctx.Args, ctx.Command.ArgsDelim))
-
-	// Type of check to look for
-	if len(checkType) > 1 {
-		checkType = strings.ToUpper(checkType)[1:]
-	}
-
-	// Returns if a user cannot be found in the message, deletes delayed response
-	if len(members) == 0 {
-		msg, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I cannot find that user!")
-
-		if err != nil {
-			log.Println(err)
+			DeleteMessageWithTime(ctx, msg.ID, 5000)
 			return
 		}
 
-		DeleteMessageWithTime(ctx, msg.ID, 7500)
-		return
+		ctx.Session.ChannelMessageSendEmbed(ctx.Channel.ID,
+			NewEmbed().
+				SetTitle(fmt.Sprintf("%s [%d]", rt.Title, rt.Count(user))).
+				SetColor(warningColor).
+				SetAuthor(fmt.Sprintf("%s#%s / %s", member.Username, member.Discriminator, member.ID), user.User.AvatarURL("256"), user.User.AvatarURL("2048")).
+				SetDescription(rt.Format(user)).
+				SetTimestamp(time.Now().Format(time.RFC3339)).MessageEmbed)
 	}
+}
+
+// ClearRecords :
+// Clears one type of a GuildUser's recorded information, named by the clear subcommand, or all of them.
+func ClearRecords(ctx Context) {
 
-	member := members[0]
+	// Member parsed from the command arguments
+	member := ctx.Arguments.User("user")
 
 	// Fetch Guild information from redis database
 	data, err := redis.Bytes(p.Do("GET", ctx.Guild.ID))
@@ -1050,7 +1023,7 @@ func Clear(ctx Context) {
 		log.Println(err)
 	}
 
-	// Check for User ID in Guild map, register user if missing
+	// Check for User ID in Guild map
 	if _, ok := g.GuildUser[member.ID]; !ok {
 		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I do not have any information on that user.")
 		return
@@ -1058,44 +1031,12 @@ func Clear(ctx Context) {
 
 	user := g.GuildUser[member.ID]
 
-	switch checkType {
-	case "WARNINGS":
-		user.Warnings = make(map[int64]Warnings)
-	case "MUTES":
-		user.Mutes = make(map[int64]Mutes)
-	case "KICKS":
-		user.Kicks = make(map[int64]Kicks)
-	case "BANS":
-		user.Bans = make(map[int64]Bans)
-	case "NICKNAMES":
-		user.Nicknames = make(map[int64]Nicknames)
-	case "USERNAME":
-		fallthrough
-	case "USERNAMES":
-		user.Usernames = make(map[int64]Usernames)
-		username := Usernames{
-			Username:      user.User.Username,
-			Discriminator: user.User.Discriminator,
-			Time:          time.Now(),
+	if ctx.Command.Name == "all" {
+		for _, rt := range recordTypes {
+			rt.Clear(&user)
 		}
-		user.Usernames[MakeTimestamp()] = username
-	case "ALL":
-		user.Warnings = make(map[int64]Warnings)
-		user.Mutes = make(map[int64]Mutes)
-		user.Kicks = make(map[int64]Kicks)
-		user.Bans = make(map[int64]Bans)
-		user.Nicknames = make(map[int64]Nicknames)
-		user.Usernames = make(map[int64]Usernames)
-		user.Usernames = make(map[int64]Usernames)
-		username := Usernames{
-			Username:      user.User.Username,
-			Discriminator: user.User.Discriminator,
-			Time:          time.Now(),
-		}
-		user.Usernames[MakeTimestamp()] = username
-	default:
-		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please choose a type to clear `<warnings|mutes|kicks|bans|usernames|nicknames|all>`")
-		return
+	} else {
+		recordTypes[ctx.Command.Name].Clear(&user)
 	}
 
 	// Set newly modified user back into GuildUser struct
@@ -1113,5 +1054,5 @@ func Clear(ctx Context) {
 		log.Println(err)
 	}
 
-	ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("✅ | %s cleared successfully!", strings.ToTitle(checkType)))
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("✅ | %s cleared successfully!", strings.Title(ctx.Command.Name)))
 }
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+	"golang.org/x/text/unicode/norm"
+)
+
+/**
+ * nicknames.go
+ * Chase Weaver
+ *
+ * This package handles nickname moderation: dehoisting, Unicode normalization and blocked names.
+ */
+
+// Nickname moderation defaults
+const (
+	DefaultModeratedName = "Moderated Nickname"
+	MaxNicknameLength    = 32
+
+	// Combining marks stacked past this on one character (i.e. zalgo text) are stripped
+	MaxStackedMarks = 2
+)
+
+// Nickname moderation reasons recorded in the nickname history
+const (
+	NameReasonDehoisted  = "dehoisted"
+	NameReasonNormalized = "normalized"
+	NameReasonBlocked    = "blocked name"
+)
+
+// confusables maps Cyrillic and Greek letters to the Latin letters they look like. They are only
+// folded in names that also use Latin letters, so names written in one script are kept.
+var confusables = map[rune]rune{
+	'а': 'a', 'в': 'B', 'е': 'e', 'к': 'k', 'м': 'M', 'н': 'H', 'о': 'o', 'р': 'p', 'с': 'c',
+	'т': 'T', 'у': 'y', 'х': 'x', 'і': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w',
+	'А': 'A', 'В': 'B', 'Е': 'E', 'К': 'K', 'М': 'M', 'Н': 'H', 'О': 'O', 'Р': 'P', 'С': 'C',
+	'Т': 'T', 'У': 'Y', 'Х': 'X', 'І': 'I', 'Ј': 'J', 'Ѕ': 'S',
+	'α': 'a', 'ο': 'o', 'ρ': 'p', 'ν': 'v', 'ι': 'i', 'κ': 'k', 'τ': 't', 'υ': 'u',
+	'Α': 'A', 'Β': 'B', 'Ε': 'E', 'Ζ': 'Z', 'Η': 'H', 'Ι': 'I', 'Κ': 'K', 'Μ': 'M', 'Ν': 'N',
+	'Ο': 'O', 'Ρ': 'P', 'Τ': 'T', 'Υ': 'Y', 'Χ': 'X',
+}
+
+// enabled :
+// Checks if any nickname moderation is enabled.
+func (n NameModerationSettings) enabled() bool {
+	return n.Dehoist || n.Normalize || len(n.BlockedNames) != 0
+}
+
+// fallback :
+// Returns the name given to members whose name is blocked or empty once moderated.
+func (n NameModerationSettings) fallback() string {
+	if len(n.ModeratedName) != 0 {
+		return n.ModeratedName
+	}
+	return DefaultModeratedName
+}
+
+// Sanitize :
+// Returns the moderated form of a name and the reasons it changed, if it did.
+func (n NameModerationSettings) Sanitize(name string) (string, string) {
+	out := name
+	var reasons []string
+
+	if n.Normalize {
+		if v := NormalizeName(out); v != out {
+			out, reasons = v, append(reasons, NameReasonNormalized)
+		}
+	}
+
+	if n.Dehoist {
+		if v := DehoistName(out); v != out {
+			out, reasons = v, append(reasons, NameReasonDehoisted)
+		}
+	}
+
+	reason := strings.Join(reasons, ", ")
+
+	if IsBlockedName(n.BlockedNames, out) || IsBlockedName(n.BlockedNames, NormalizeName(out)) {
+		return n.fallback(), NameReasonBlocked
+	}
+
+	if len(strings.TrimSpace(out)) == 0 {
+		return n.fallback(), reason
+	}
+
+	if runes := []rune(out); len(runes) > MaxNicknameLength {
+		out = string(runes[:MaxNicknameLength])
+	}
+
+	return out, reason
+}
+
+// NormalizeName :
+// Folds stylized Unicode (i.e. mathematical or full width letters) and lookalike letters mixed
+// into Latin names into plain characters, strips zalgo and removes invisible formatting
+// characters. Accents and names written in other scripts are kept.
+func NormalizeName(name string) string {
+	name = norm.NFKC.String(name)
+
+	latin := strings.IndexFunc(name, func(r rune) bool {
+		return unicode.Is(unicode.Latin, r)
+	}) != -1
+
+	var b strings.Builder
+	runes := []rune(name)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if unicode.Is(unicode.Cf, r) || unicode.IsControl(r) {
+			continue
+		}
+
+		if v, ok := confusables[r]; ok && latin {
+			r = v
+		}
+		b.WriteRune(r)
+
+		// Keeps the combining marks of the character unless they are stacked
+		j := i + 1
+		for j < len(runes) && unicode.In(runes[j], unicode.Mn, unicode.Me) {
+			j++
+		}
+
+		if j-i-1 <= MaxStackedMarks {
+			b.WriteString(string(runes[i+1 : j]))
+		}
+		i = j - 1
+	}
+
+	return strings.TrimSpace(norm.NFC.String(b.String()))
+}
+
+// DehoistName :
+// Strips leading characters used to sort a name to the top of the member list, such as
+// punctuation, symbols, spaces and zero-width characters.
+func DehoistName(name string) string {
+	return strings.TrimLeftFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// IsBlockedName :
+// Checks if a name contains any of the blocked names, ignoring case.
+func IsBlockedName(blocked []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, v := range blocked {
+		if len(v) != 0 && strings.Contains(name, strings.ToLower(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ModerateMemberName :
+// Changes a member's nickname if their display name breaks the guild's name rules, recording
+// the change in their nickname history. Returns true if the nickname was changed.
+func ModerateMemberName(s *discordgo.Session, g *Guild, m *discordgo.Member) bool {
+
+	if !g.NameModeration.enabled() || IsAutomodExempt(s, *g, m.User) {
+		return false
+	}
+
+	name := m.Nick
+	if len(name) == 0 {
+		name = m.User.Username
+	}
+
+	nick, reason := g.NameModeration.Sanitize(name)
+	if nick == name {
+		return false
+	}
+
+	// Reset the nickname if the username is already acceptable
+	if nick == m.User.Username {
+		nick = ""
+	}
+
+	err := s.GuildMemberNickname(g.Guild.ID, m.User.ID, nick)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	user, ok := g.GuildUser[m.User.ID]
+	if !ok {
+		user = RegisterNewUser(m.User)
+	}
+
+	recorded := nick
+	if len(recorded) == 0 {
+		recorded = "RESET NICKNAME"
+	}
+
+	user.Nicknames[NicknameKey(user.Nicknames)] = Nicknames{
+		Nickname: recorded,
+		Reason:   reason,
+		Time:     time.Now(),
+	}
+	g.GuildUser[m.User.ID] = user
+
+	return true
+}
+
+// NicknameKey :
+// Returns the current timestamp as a key of a nickname history, moved past any entry already
+// recorded in the same millisecond so neither replaces the other.
+func NicknameKey(nicknames map[int64]Nicknames) int64 {
+	key := MakeTimestamp()
+	for {
+		if _, ok := nicknames[key]; !ok {
+			return key
+		}
+		key++
+	}
+}
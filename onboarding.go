@@ -0,0 +1,182 @@
+package main
+
+import (
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+/**
+ * onboarding.go
+ * Chase Weaver
+ *
+ * This package handles the join onboarding flow. New members are given an unverified role
+ * which is removed once they react to or answer the rules prompt.
+ */
+
+func init() {
+	RegisterNewCommand(Command{
+		Name:            "onboarding",
+		Func:            Onboarding,
+		Enabled:         true,
+		NSFWOnly:        false,
+		IgnoreSelf:      true,
+		IgnoreBots:      true,
+		Cooldown:        0,
+		RunIn:           []string{"Text"},
+		Aliases:         []string{"rulesprompt"},
+		UserPermissions: []string{"Bot Owner", "Manage Server"},
+		Usage:           []string{},
+		Description:     "Posts the rules prompt new members verify with.",
+	})
+}
+
+// customEmoji matches a custom emoji mention, i.e. <:name:id> or <a:name:id>
+var customEmoji = regexp.MustCompile(`^<a?:(\w+:\d+)>$`)
+
+// Onboarding :
+// Posts the onboarding prompt in the onboarding channel and reacts with the onboarding emoji.
+func Onboarding(ctx Context) {
+
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	o := g.Onboarding
+	if o.Channel == nil || len(o.Prompt) == 0 {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please set the `Onboarding Channel` and `Onboarding Prompt` first.")
+		return
+	}
+
+	e := NewEmbed().
+		SetTitle("Welcome to " + ctx.Guild.Name).
+		SetDescription(o.Prompt).
+		SetColor(createColor)
+
+	if len(o.Emoji) != 0 {
+		e.SetFooter("React to this message to gain access to the server.")
+	}
+
+	msg, err := ctx.Session.ChannelMessageSendEmbed(o.Channel.ID, e.Truncate().MessageEmbed)
+	if err != nil {
+		log.Println(err)
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I cannot send messages in the onboarding channel.")
+		return
+	}
+
+	if len(o.Emoji) != 0 {
+		err = ctx.Session.MessageReactionAdd(o.Channel.ID, msg.ID, o.Emoji)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	g.Onboarding.PromptMessageID = msg.ID
+	err = PackGuildStruct(ctx.Guild.ID, g)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, "✅ | Onboarding prompt posted!")
+}
+
+// ParseReactionEmoji :
+// Returns an emoji in the form used by reactions, name:id for custom emojis.
+func ParseReactionEmoji(s string) string {
+	s = strings.TrimSpace(s)
+	if match := customEmoji.FindStringSubmatch(s); match != nil {
+		return match[1]
+	}
+	return s
+}
+
+// ReactionEmoji :
+// Returns the emoji of a reaction in the form used by ParseReactionEmoji.
+func ReactionEmoji(e discordgo.Emoji) string {
+	if len(e.ID) != 0 {
+		return e.Name + ":" + e.ID
+	}
+	return e.Name
+}
+
+// StartOnboarding :
+// Gives a new member the unverified role if onboarding is enabled and they are not automod exempt.
+func StartOnboarding(s *discordgo.Session, g Guild, userID string) {
+	if !g.Onboarding.Enabled || g.Onboarding.UnverifiedRole == nil {
+		return
+	}
+
+	if IsAutomodExempt(s, g, &discordgo.User{ID: userID}) {
+		return
+	}
+
+	err := s.GuildMemberRoleAdd(g.Guild.ID, userID, g.Onboarding.UnverifiedRole.ID)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// VerifyMember :
+// Removes the unverified role from a member.
+func VerifyMember(s *discordgo.Session, g Guild, userID string) bool {
+	if g.Onboarding.UnverifiedRole == nil {
+		return false
+	}
+
+	member, err := FetchMember(s, g.Guild.ID, userID)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	if !Contains(member.Roles, g.Onboarding.UnverifiedRole.ID) {
+		return false
+	}
+
+	err = s.GuildMemberRoleRemove(g.Guild.ID, userID, g.Onboarding.UnverifiedRole.ID)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	return true
+}
+
+// HandleOnboardingReaction :
+// Verifies a member reacting to the onboarding prompt with the onboarding emoji.
+func HandleOnboardingReaction(s *discordgo.Session, g Guild, r *discordgo.MessageReaction) {
+	o := g.Onboarding
+	if !o.Enabled || len(o.Emoji) == 0 || r.MessageID != o.PromptMessageID || ReactionEmoji(r.Emoji) != o.Emoji {
+		return
+	}
+
+	VerifyMember(s, g, r.UserID)
+}
+
+// HandleOnboardingAnswer :
+// Verifies a member answering the onboarding prompt in the onboarding channel.
+// Returns true if the message was an onboarding answer.
+func HandleOnboardingAnswer(s *discordgo.Session, g Guild, m *discordgo.Message) bool {
+	o := g.Onboarding
+	if !o.Enabled || len(o.Answer) == 0 || o.Channel == nil || m.ChannelID != o.Channel.ID || m.Author.Bot {
+		return false
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(m.Content), o.Answer) {
+		return false
+	}
+
+	VerifyMember(s, g, m.Author.ID)
+
+	err := s.ChannelMessageDelete(m.ChannelID, m.ID)
+	if err != nil {
+		log.Println(err)
+	}
+
+	return true
+}
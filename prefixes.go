@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+/**
+ * prefixes.go
+ * Chase Weaver
+ *
+ * This package handles matching command prefixes: the guild prefix, its extra prefixes, and
+ * mentions of the bot, which always work so a forgotten prefix can be recovered.
+ */
+
+// AllPrefixes :
+// Returns the guild prefix followed by its extra prefixes.
+func (g Guild) AllPrefixes() []string {
+	prefixes := []string{g.GuildPrefix}
+	for _, v := range g.Prefixes {
+		if len(v) != 0 && !Contains(prefixes, v) {
+			prefixes = append(prefixes, v)
+		}
+	}
+	return prefixes
+}
+
+// MatchPrefix :
+// Returns the prefix a message starts with and the content after it. Mentions of the bot are
+// checked first, then the guild's prefixes from longest to shortest, ignoring case if the guild
+// is case insensitive.
+func MatchPrefix(s *discordgo.Session, g Guild, content string) (prefix, rest string, mention, ok bool) {
+
+	if s.State.User != nil {
+		for _, v := range []string{"<@" + s.State.User.ID + ">", "<@!" + s.State.User.ID + ">"} {
+			if strings.HasPrefix(content, v) {
+				return v, strings.TrimLeftFunc(content[len(v):], unicode.IsSpace), true, true
+			}
+		}
+	}
+
+	prefixes := g.AllPrefixes()
+	sort.SliceStable(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+
+	for _, v := range prefixes {
+		if len(v) == 0 || len(content) < len(v) {
+			continue
+		}
+
+		if content[:len(v)] == v || (g.CaseInsensitive && strings.EqualFold(content[:len(v)], v)) {
+			return v, content[len(v):], false, true
+		}
+	}
+
+	return "", "", false, false
+}
+
+// FormatPrefixes :
+// Returns the reply to a bare mention of the bot, listing the guild's prefixes.
+func FormatPrefixes(g Guild) string {
+	var prefixes []string
+	for _, v := range g.AllPrefixes() {
+		prefixes = append(prefixes, "`"+v+"`")
+	}
+
+	return fmt.Sprintf("👋 | My prefixes here are %s, or you can mention me. Run `%shelp` for a list of commands.", strings.Join(prefixes, ", "), g.GuildPrefix)
+}
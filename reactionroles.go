@@ -0,0 +1,447 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gomodule/redigo/redis"
+)
+
+/**
+ * reactionroles.go
+ * Chase Weaver
+ *
+ * This package handles reaction roles, granting and revoking roles bound to emojis
+ * when members react to a message.
+ */
+
+func init() {
+	RegisterNewCommand(Command{
+		Name:            "reactionrole",
+		Func:            ReactionRole,
+		Enabled:         true,
+		NSFWOnly:        false,
+		IgnoreSelf:      true,
+		IgnoreBots:      true,
+		Cooldown:        0,
+		RunIn:           []string{"Text"},
+		Aliases:         []string{"rr", "reactionroles"},
+		UserPermissions: []string{"Bot Owner", "Manage Roles"},
+		Usage:           []string{"<add|remove|mode|clear|list>", "[message ID|link]", "[emoji|mode]", "[@role]"},
+		Description:     "Binds emojis on a message to roles members get by reacting.",
+	})
+}
+
+// Reaction role modes
+const (
+	ReactionRoleToggle = "toggle"
+	ReactionRoleVerify = "verify"
+	ReactionRoleUnique = "unique"
+)
+
+// MaxReactionRoles is the most reactions Discord allows on a message
+const MaxReactionRoles = 20
+
+// messageLink matches a Discord message link
+var messageLink = regexp.MustCompile(`channels/(\d+|@me)/(\d+)/(\d+)`)
+
+// ReactionRole :
+// Manages the reaction role bindings of a guild.
+// [add|remove|mode|clear|list] [message ID|link] [emoji|mode] [@role]
+func ReactionRole(ctx Context) {
+
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if g.ReactionRoles == nil {
+		g.ReactionRoles = make(map[string]*ReactionRoleMessage)
+	}
+
+	if len(ctx.Args) == 0 {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | Usage: `%sreactionrole %s`", g.GuildPrefix, strings.Join(ctx.Command.Usage, " ")))
+		return
+	}
+
+	sub := strings.ToLower(ctx.Args[0])
+	if sub == "list" {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, FormatString(FormatReactionRoles(g), "asciidoc"))
+		return
+	}
+
+	if len(ctx.Args) < 2 {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please give a message ID or link.")
+		return
+	}
+
+	channelID, messageID := parseMessageReference(ctx.Channel.ID, ctx.Args[1])
+	rr, ok := g.ReactionRoles[messageID]
+
+	switch sub {
+	case "add":
+		if len(ctx.Args) < 4 {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please give an emoji and a role.")
+			return
+		}
+
+		roles := FetchMessageContentRoles(ctx, strings.Join(ctx.Args[3:], " "))
+		if len(roles) == 0 {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I cannot find that role.")
+			return
+		}
+
+		if !ok {
+			if _, err := ctx.Session.ChannelMessage(channelID, messageID); err != nil {
+				ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I cannot find that message.")
+				return
+			}
+
+			rr = &ReactionRoleMessage{
+				ChannelID: channelID,
+				MessageID: messageID,
+				Mode:      ReactionRoleToggle,
+				Roles:     make(map[string]*discordgo.Role),
+			}
+			g.ReactionRoles[messageID] = rr
+		}
+
+		emoji := ParseReactionEmoji(ctx.Args[2])
+		if _, exists := rr.Roles[emoji]; !exists && len(rr.Roles) >= MaxReactionRoles {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | A message can only have %d reaction roles.", MaxReactionRoles))
+			return
+		}
+
+		err = ctx.Session.MessageReactionAdd(rr.ChannelID, rr.MessageID, emoji)
+		if err != nil {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I cannot react with that emoji.")
+			return
+		}
+
+		rr.Roles[emoji] = roles[0]
+	case "remove":
+		if !ok || len(ctx.Args) < 3 {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please give a message with reaction roles and an emoji.")
+			return
+		}
+
+		emoji := ParseReactionEmoji(ctx.Args[2])
+		delete(rr.Roles, emoji)
+
+		err = ctx.Session.MessageReactionRemove(rr.ChannelID, rr.MessageID, emoji, "@me")
+		if err != nil {
+			log.Println(err)
+		}
+
+		if len(rr.Roles) == 0 {
+			delete(g.ReactionRoles, messageID)
+		}
+	case "mode":
+		if !ok || len(ctx.Args) < 3 {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | Please give a message with reaction roles and a mode.")
+			return
+		}
+
+		switch mode := strings.ToLower(ctx.Args[2]); mode {
+		case ReactionRoleToggle, ReactionRoleVerify, ReactionRoleUnique:
+			rr.Mode = mode
+		default:
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | Please choose `%s`, `%s` or `%s`.", ReactionRoleToggle, ReactionRoleVerify, ReactionRoleUnique))
+			return
+		}
+	case "clear":
+		if !ok {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | That message has no reaction roles.")
+			return
+		}
+
+		delete(g.ReactionRoles, messageID)
+	default:
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | Usage: `%sreactionrole %s`", g.GuildPrefix, strings.Join(ctx.Command.Usage, " ")))
+		return
+	}
+
+	err = PackGuildStruct(ctx.Guild.ID, g)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	IndexReactionRoles(ctx.Guild.ID, g)
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, "✅ | Reaction roles updated!")
+}
+
+// parseMessageReference :
+// Returns the channel and message ID of a message link, or of a message ID in the given channel.
+func parseMessageReference(channelID, ref string) (string, string) {
+	if match := messageLink.FindStringSubmatch(ref); match != nil {
+		return match[2], match[3]
+	}
+	return channelID, ref
+}
+
+// FormatReactionRoles :
+// Returns a string of every reaction role message and its bindings.
+func FormatReactionRoles(g Guild) string {
+	if len(g.ReactionRoles) == 0 {
+		return "No reaction roles have been set."
+	}
+
+	var ids []string
+	for id := range g.ReactionRoles {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var str string
+	for _, id := range ids {
+		rr := g.ReactionRoles[id]
+		str += fmt.Sprintf("== %s (%s) ==\n", rr.MessageID, rr.Mode)
+
+		for emoji, role := range rr.Roles {
+			str += fmt.Sprintf("%-24s ::   %s\n", emoji, role.Name)
+		}
+
+		str += "\n"
+	}
+
+	return strings.TrimSuffix(str, "\n\n")
+}
+
+// HandleReactionRoleAdd :
+// Grants the role bound to a reaction. In unique mode, other roles of the message are revoked
+// along with their reactions.
+func HandleReactionRoleAdd(s *discordgo.Session, g Guild, r *discordgo.MessageReaction) {
+
+	rr, ok := g.ReactionRoles[r.MessageID]
+	if !ok {
+		return
+	}
+
+	emoji := ReactionEmoji(r.Emoji)
+	role, ok := rr.Roles[emoji]
+	if !ok {
+		return
+	}
+
+	if rr.Mode == ReactionRoleUnique {
+		member, err := FetchMember(s, g.Guild.ID, r.UserID)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		for e, v := range rr.Roles {
+			if e == emoji || !Contains(member.Roles, v.ID) {
+				continue
+			}
+
+			err := s.GuildMemberRoleRemove(g.Guild.ID, r.UserID, v.ID)
+			if err != nil {
+				log.Println(err)
+			}
+
+			err = s.MessageReactionRemove(rr.ChannelID, rr.MessageID, e, r.UserID)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}
+
+	err := s.GuildMemberRoleAdd(g.Guild.ID, r.UserID, role.ID)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// HandleReactionRoleRemove :
+// Revokes the role bound to a removed reaction, unless the message is in verify mode.
+func HandleReactionRoleRemove(s *discordgo.Session, g Guild, r *discordgo.MessageReaction) {
+
+	rr, ok := g.ReactionRoles[r.MessageID]
+	if !ok || rr.Mode == ReactionRoleVerify {
+		return
+	}
+
+	role, ok := rr.Roles[ReactionEmoji(r.Emoji)]
+	if !ok {
+		return
+	}
+
+	err := s.GuildMemberRoleRemove(g.Guild.ID, r.UserID, role.ID)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// IndexReactionRoles :
+// Replaces the set of a guild's reaction role message IDs, so deleted messages can be checked
+// without reading the guild.
+func IndexReactionRoles(guildID string, g Guild) {
+	conn := pool.Get()
+	defer conn.Close()
+
+	key := reactionRolesKey(guildID)
+	conn.Send("MULTI")
+	conn.Send("DEL", key)
+
+	if len(g.ReactionRoles) != 0 {
+		args := redis.Args{}.Add(key)
+		for id := range g.ReactionRoles {
+			args = args.Add(id)
+		}
+		conn.Send("SADD", args...)
+	}
+
+	_, err := conn.Do("EXEC")
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// RemoveReactionRoleMessages :
+// Removes the reaction role bindings of deleted messages. Returns true if any were removed.
+func RemoveReactionRoleMessages(guildID string, messageIDs ...string) bool {
+
+	// Most deleted messages have no reaction roles, so the guild is only read when one does
+	n, err := redis.Int(p.Do("SREM", redis.Args{}.Add(reactionRolesKey(guildID)).AddFlat(messageIDs)...))
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	if n == 0 {
+		return false
+	}
+
+	g, err := UnpackGuildStruct(guildID)
+	if err != nil {
+		return false
+	}
+
+	removed := false
+	for _, id := range messageIDs {
+		if _, ok := g.ReactionRoles[id]; ok {
+			delete(g.ReactionRoles, id)
+			removed = true
+		}
+	}
+
+	if !removed {
+		return false
+	}
+
+	err = PackGuildStruct(guildID, g)
+	if err != nil {
+		log.Println(err)
+	}
+
+	return true
+}
+
+// SyncReactionRoles :
+// Grants roles for reactions added while the bot was offline and removes bindings of
+// messages deleted in the meantime. Reactions removed while offline are not revoked.
+func SyncReactionRoles(s *discordgo.Session, guildID string) {
+
+	g, err := UnpackGuildStruct(guildID)
+	if err != nil {
+		return
+	}
+
+	IndexReactionRoles(guildID, g)
+
+	var deleted []string
+	for id, rr := range g.ReactionRoles {
+		_, err := s.ChannelMessage(rr.ChannelID, rr.MessageID)
+		if isNotFound(err) {
+			deleted = append(deleted, id)
+			continue
+		} else if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		for emoji, role := range rr.Roles {
+			users, err := FetchReactionUsers(s, rr.ChannelID, rr.MessageID, emoji)
+			if err != nil {
+				log.Println(err)
+			}
+
+			for _, u := range users {
+				if u.ID == s.State.User.ID {
+					continue
+				}
+
+				member, err := FetchMember(s, guildID, u.ID)
+				if err != nil || Contains(member.Roles, role.ID) {
+					continue
+				}
+
+				HandleReactionRoleAdd(s, g, &discordgo.MessageReaction{
+					UserID:    u.ID,
+					MessageID: rr.MessageID,
+					ChannelID: rr.ChannelID,
+					Emoji:     reactionEmojiFromString(emoji),
+				})
+			}
+		}
+	}
+
+	if len(deleted) != 0 {
+		RemoveReactionRoleMessages(guildID, deleted...)
+	}
+}
+
+// FetchReactionUsers :
+// Returns every user who reacted to a message with an emoji, 100 per request.
+func FetchReactionUsers(s *discordgo.Session, channelID, messageID, emoji string) ([]*discordgo.User, error) {
+	var users []*discordgo.User
+
+	after := ""
+	for {
+		uri := discordgo.EndpointMessageReactions(channelID, messageID, emoji) + "?limit=100"
+		if len(after) != 0 {
+			uri += "&after=" + after
+		}
+
+		body, err := s.RequestWithBucketID("GET", uri, nil, discordgo.EndpointMessageReactions(channelID, messageID, ""))
+		if err != nil {
+			return users, err
+		}
+
+		var page []*discordgo.User
+		err = json.Unmarshal(body, &page)
+		if err != nil {
+			return users, err
+		}
+
+		users = append(users, page...)
+		if len(page) < 100 {
+			return users, nil
+		}
+		after = page[len(page)-1].ID
+	}
+}
+
+// reactionRolesKey :
+// Returns the redis key of the set of a guild's reaction role message IDs.
+func reactionRolesKey(guildID string) string {
+	return fmt.Sprintf("reactionroles:%s", guildID)
+}
+
+// reactionEmojiFromString :
+// Returns the emoji of a name:id or unicode emoji string.
+func reactionEmojiFromString(emoji string) discordgo.Emoji {
+	if i := strings.LastIndex(emoji, ":"); i != -1 {
+		return discordgo.Emoji{Name: emoji[:i], ID: emoji[i+1:]}
+	}
+	return discordgo.Emoji{Name: emoji}
+}
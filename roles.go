@@ -0,0 +1,359 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+/**
+ * roles.go
+ * Chase Weaver
+ *
+ * This package bundles the role management moderation commands.
+ */
+
+func init() {
+	RegisterNewCommand(Command{
+		Name:            "role",
+		Enabled:         true,
+		NSFWOnly:        false,
+		IgnoreSelf:      true,
+		IgnoreBots:      true,
+		Cooldown:        0,
+		RunIn:           []string{"Text"},
+		Aliases:         []string{},
+		UserPermissions: []string{"Bot Owner", "Manage Roles"},
+		Usage:           []string{},
+		Subcommands: []Command{
+			{
+				Name:        "add",
+				Func:        RoleAdd,
+				Params:      []Param{{Name: "member", Type: ArgMember, Variadic: true}, {Name: "role", Type: ArgRole}},
+				Description: "Adds a role to members.",
+			},
+			{
+				Name:        "remove",
+				Func:        RoleRemove,
+				Params:      []Param{{Name: "member", Type: ArgMember, Variadic: true}, {Name: "role", Type: ArgRole}},
+				Description: "Removes a role from members.",
+			},
+			{
+				Name: "all",
+				Func: RoleAll,
+				Params: []Param{
+					{Name: "role", Type: ArgRole},
+					{Name: "humans|bots", Type: ArgEnum, Choices: []string{"humans", "bots"}, Optional: true},
+					{Name: "filter role", Type: ArgRole, Optional: true},
+				},
+				Timeout:     RoleBulkTimeout,
+				Description: "Adds a role to every member, or to humans, bots, or members with another role.",
+			},
+			{
+				Name:        "info",
+				Func:        RoleInfo,
+				Params:      []Param{{Name: "role", Type: ArgRole}},
+				Description: "Shows information about a role.",
+			},
+		},
+		Description: "Adds or removes a role from members, adds a role to every member, or shows role information.",
+	})
+}
+
+// Bulk role operation pacing
+const (
+	RoleBulkInterval     = 500 * time.Millisecond
+	RoleProgressInterval = 5 * time.Second
+	RoleBulkTimeout      = 2 * time.Hour
+)
+
+var (
+	roleJobs   = make(map[string]bool)
+	roleJobsMu sync.Mutex
+)
+
+// RoleAdd :
+// Adds a role to members.
+// [@Member(s)] [@Role]
+func RoleAdd(ctx Context) {
+	RoleChange(ctx, true)
+}
+
+// RoleRemove :
+// Removes a role from members.
+// [@Member(s)] [@Role]
+func RoleRemove(ctx Context) {
+	RoleChange(ctx, false)
+}
+
+// RoleChange :
+// Adds or removes a role from the members in the arguments.
+func RoleChange(ctx Context, add bool) {
+
+	g := ctx.Settings
+	role := ctx.Arguments.Role("role")
+	if !checkRoleHierarchy(ctx, role) {
+		return
+	}
+
+	title, verb, clr := "Member Role Added", "given", createColor
+	if !add {
+		title, verb, clr = "Member Role Removed", "removed from", removeColor
+	}
+
+	var done []string
+	for _, m := range ctx.Arguments.Members("member") {
+		u := m.User
+
+		var err error
+		if add {
+			err = ctx.Session.GuildMemberRoleAdd(ctx.Guild.ID, u.ID, role.ID)
+		} else {
+			err = ctx.Session.GuildMemberRoleRemove(ctx.Guild.ID, u.ID, role.ID)
+		}
+
+		if err != nil {
+			log.Println(err)
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | I could not change the roles of `%s#%s`.", u.Username, u.Discriminator))
+			continue
+		}
+
+		done = append(done, fmt.Sprintf("`%s#%s`", u.Username, u.Discriminator))
+
+		SendGuildLogEmbed(ctx.Session, g, LogEventModeration,
+			NewEmbed().
+				SetTitle(title).
+				SetColor(clr).
+				SetAuthor(fmt.Sprintf("%s#%s / %s", u.Username, u.Discriminator, u.ID), u.AvatarURL("256"), u.AvatarURL("2048")).
+				AddField("Author", fmt.Sprintf("%s#%s / %s", ctx.Event.Author.Username, ctx.Event.Author.Discriminator, ctx.Event.Author.ID)).
+				AddField("Role", fmt.Sprintf("%s / %s", role.Name, role.ID)).
+				AddField("Channel", fmt.Sprintf("<#%s>", ctx.Channel.ID)).
+				SetTimestamp(time.Now().Format(time.RFC3339)).MessageEmbed)
+	}
+
+	if len(done) != 0 {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("✅ | `%s` has been %s %s.", role.Name, verb, strings.Join(done, ", ")))
+	}
+}
+
+// RoleAll :
+// Adds a role to every member, or to humans, bots, or members with another role.
+// Members are updated one at a time with progress reported in the channel.
+// [@Role] [humans|bots|@Role]
+func RoleAll(ctx Context) {
+
+	g := ctx.Settings
+	args := ctx.Arguments
+
+	role := args.Role("role")
+	if !checkRoleHierarchy(ctx, role) {
+		return
+	}
+
+	// Filter members by type or by another role
+	filter := "everyone"
+	filterRole := args.Role("filter role")
+	if filterRole != nil {
+		filter = "members with " + filterRole.Name
+	} else if args.Has("humans|bots") {
+		filter = args.String("humans|bots")
+	}
+
+	roleJobsMu.Lock()
+	if roleJobs[ctx.Guild.ID] {
+		roleJobsMu.Unlock()
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | A bulk role change is already running in this guild.")
+		return
+	}
+	roleJobs[ctx.Guild.ID] = true
+	roleJobsMu.Unlock()
+
+	defer func() {
+		roleJobsMu.Lock()
+		delete(roleJobs, ctx.Guild.ID)
+		roleJobsMu.Unlock()
+	}()
+
+	members, err := FetchAllMembers(ctx, ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I could not fetch the members of this guild.")
+		return
+	}
+
+	var targets []*discordgo.Member
+	for _, m := range members {
+		switch {
+		case Contains(m.Roles, role.ID):
+		case filter == "humans" && m.User.Bot:
+		case filter == "bots" && !m.User.Bot:
+		case filterRole != nil && !Contains(m.Roles, filterRole.ID):
+		default:
+			targets = append(targets, m)
+		}
+	}
+
+	if len(targets) == 0 {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | Every matching member already has `%s`.", role.Name))
+		return
+	}
+
+	progress, err := ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("⏳ | Adding `%s` to %s... 0/%d", role.Name, filter, len(targets)))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	var changed []string
+	failed := 0
+	last := time.Now()
+	tick := time.NewTicker(RoleBulkInterval)
+	defer tick.Stop()
+
+	done := 0
+	for i, m := range targets {
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+		}
+
+		// Stops early if the command times out or the bot shuts down
+		if ctx.Err() != nil {
+			break
+		}
+		done = i + 1
+
+		err := ctx.Session.GuildMemberRoleAdd(ctx.Guild.ID, m.User.ID, role.ID)
+		if err != nil {
+			log.Println(err)
+			failed++
+		} else {
+			changed = append(changed, fmt.Sprintf("%s#%s / %s", m.User.Username, m.User.Discriminator, m.User.ID))
+		}
+
+		if time.Since(last) >= RoleProgressInterval {
+			last = time.Now()
+			ctx.Session.ChannelMessageEdit(ctx.Channel.ID, progress.ID, fmt.Sprintf("⏳ | Adding `%s` to %s... %d/%d", role.Name, filter, i+1, len(targets)))
+		}
+	}
+
+	if done < len(targets) {
+		ctx.Session.ChannelMessageEdit(ctx.Channel.ID, progress.ID, fmt.Sprintf("❌ | Stopped after %d/%d members. Added `%s` to %d member(s), %d failed.", done, len(targets), role.Name, len(changed), failed))
+	} else {
+		ctx.Session.ChannelMessageEdit(ctx.Channel.ID, progress.ID, fmt.Sprintf("✅ | Added `%s` to %d member(s), %d failed.", role.Name, len(changed), failed))
+	}
+
+	// Log the bulk change with the list of changed members attached
+	SendGuildLog(ctx.Session, g, LogEventModeration, &discordgo.MessageSend{
+		Embed: NewEmbed().
+			SetTitle("Bulk Role Added").
+			SetColor(createColor).
+			AddField("Author", fmt.Sprintf("%s#%s / %s", ctx.Event.Author.Username, ctx.Event.Author.Discriminator, ctx.Event.Author.ID)).
+			AddField("Role", fmt.Sprintf("%s / %s", role.Name, role.ID)).
+			AddField("Filter", filter).
+			AddField("Members", fmt.Sprintf("%d changed, %d failed", len(changed), failed)).
+			SetTimestamp(time.Now().Format(time.RFC3339)).MessageEmbed,
+		Files: []*discordgo.File{{
+			Name:        "members.txt",
+			ContentType: "text/plain",
+			Reader:      strings.NewReader(strings.Join(changed, "\n")),
+		}},
+	})
+}
+
+// RoleInfo :
+// Shows information about a role.
+// [@Role]
+func RoleInfo(ctx Context) {
+
+	role := ctx.Arguments.Role("role")
+
+	count := 0
+	if guild, err := ctx.Session.State.Guild(ctx.Guild.ID); err == nil {
+		for _, m := range guild.Members {
+			if Contains(m.Roles, role.ID) {
+				count++
+			}
+		}
+	}
+
+	var perms []string
+	for name, bit := range permissions {
+		if role.Permissions&bit == bit && !strings.HasPrefix(name, "All") {
+			perms = append(perms, name)
+		}
+	}
+	sort.Strings(perms)
+
+	if len(perms) == 0 {
+		perms = []string{"None"}
+	}
+
+	created, err := CreationTime(role.ID)
+	if err != nil {
+		log.Println(err)
+	}
+
+	ctx.Session.ChannelMessageSendEmbed(ctx.Channel.ID,
+		NewEmbed().
+			SetTitle(role.Name).
+			SetColor(role.Color).
+			AddField("ID", role.ID).
+			AddField("Color", fmt.Sprintf("#%06X", role.Color)).
+			AddField("Position", fmt.Sprintf("%d", role.Position)).
+			AddField("Members", fmt.Sprintf("%d", count)).
+			AddField("Hoisted", fmt.Sprintf("%t", role.Hoist)).
+			AddField("Mentionable", fmt.Sprintf("%t", role.Mentionable)).
+			AddField("Managed", fmt.Sprintf("%t", role.Managed)).
+			AddField("Created", created.Format("01/02/06 03:04:05 PM MST")).
+			InlineAllFields().
+			AddField("Permissions", strings.Join(perms, ", ")).
+			Truncate().MessageEmbed)
+}
+
+// FetchAllMembers :
+// Returns every member of a guild, paging through the members endpoint until the command is
+// cancelled.
+func FetchAllMembers(ctx Context, guildID string) ([]*discordgo.Member, error) {
+	var all []*discordgo.Member
+	after := ""
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		members, err := ctx.Session.GuildMembers(guildID, after, 1000)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, members...)
+		if len(members) < 1000 {
+			return all, nil
+		}
+
+		after = members[len(members)-1].User.ID
+	}
+}
+
+// checkRoleHierarchy :
+// Replies and returns false if the author or the bot cannot manage a role.
+func checkRoleHierarchy(ctx Context, role *discordgo.Role) bool {
+	if !MemberCanManageRole(ctx.Session, ctx.Guild.ID, ctx.Event.Author.ID, role) && ctx.Event.Author.ID != conf.OwnerID {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | You cannot manage that role, it is above your highest role.")
+		return false
+	}
+
+	if !BotCanManageRole(ctx.Session, ctx.Guild.ID, role) {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I cannot manage that role, it is above my highest role.")
+		return false
+	}
+
+	return true
+}
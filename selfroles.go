@@ -0,0 +1,370 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+/**
+ * selfroles.go
+ * Chase Weaver
+ *
+ * This package handles self-assignable roles members can give themselves with iam / iamnot.
+ */
+
+func init() {
+	RegisterNewCommand(Command{
+		Name:            "iam",
+		Func:            IAm,
+		Enabled:         true,
+		NSFWOnly:        false,
+		IgnoreSelf:      true,
+		IgnoreBots:      true,
+		Cooldown:        0,
+		RunIn:           []string{"Text"},
+		Aliases:         []string{},
+		UserPermissions: []string{},
+		Usage:           []string{"<role>"},
+		Description:     "Gives yourself a self-assignable role.",
+	})
+
+	RegisterNewCommand(Command{
+		Name:            "iamnot",
+		Func:            IAmNot,
+		Enabled:         true,
+		NSFWOnly:        false,
+		IgnoreSelf:      true,
+		IgnoreBots:      true,
+		Cooldown:        0,
+		RunIn:           []string{"Text"},
+		Aliases:         []string{},
+		UserPermissions: []string{},
+		Usage:           []string{"<role>"},
+		Description:     "Removes a self-assignable role from yourself.",
+	})
+
+	RegisterNewCommand(Command{
+		Name:            "roles",
+		Func:            Roles,
+		Enabled:         true,
+		NSFWOnly:        false,
+		IgnoreSelf:      true,
+		IgnoreBots:      true,
+		Cooldown:        0,
+		RunIn:           []string{"Text"},
+		Aliases:         []string{"selfroles"},
+		UserPermissions: []string{},
+		Usage:           []string{},
+		Description:     "Lists self-assignable roles.",
+	})
+
+	RegisterNewCommand(Command{
+		Name:            "selfrole",
+		Enabled:         true,
+		NSFWOnly:        false,
+		IgnoreSelf:      true,
+		IgnoreBots:      true,
+		Cooldown:        0,
+		RunIn:           []string{"Text"},
+		Aliases:         []string{},
+		UserPermissions: []string{"Bot Owner", "Manage Roles"},
+		Usage:           []string{},
+		Subcommands: []Command{
+			{
+				Name:        "add",
+				Func:        SelfRoleAdd,
+				Params:      []Param{{Name: "role", Type: ArgRole}},
+				Description: "Makes a role self-assignable.",
+			},
+			{
+				Name:        "remove",
+				Func:        SelfRoleRemove,
+				Params:      []Param{{Name: "role", Type: ArgRole}},
+				Description: "Makes a role no longer self-assignable.",
+			},
+			{
+				Name:        "group",
+				Func:        SelfRoleGroup,
+				Params:      []Param{{Name: "group|none", Type: ArgString}, {Name: "role", Type: ArgRole}},
+				Description: "Sets the exclusive group of a self-assignable role, members can only have one role of a group.",
+			},
+		},
+		Description: "Manages self-assignable roles and their exclusive groups.",
+	})
+}
+
+// IAm :
+// Gives the author a self-assignable role, removing other roles of its exclusive group.
+// [role]
+func IAm(ctx Context) {
+
+	g, role, ok := fetchSelfRole(ctx)
+	if !ok {
+		return
+	}
+
+	member, err := FetchMember(ctx.Session, ctx.Guild.ID, ctx.Event.Author.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if Contains(member.Roles, role.Role.ID) {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | You already have that role.")
+		return
+	}
+
+	// Remove the other roles of the same group
+	if len(role.Group) != 0 {
+		for _, v := range g.SelfRoles {
+			if v.Group != role.Group || v.Role.ID == role.Role.ID || !Contains(member.Roles, v.Role.ID) {
+				continue
+			}
+
+			err := ctx.Session.GuildMemberRoleRemove(ctx.Guild.ID, member.User.ID, v.Role.ID)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}
+
+	err = ctx.Session.GuildMemberRoleAdd(ctx.Guild.ID, member.User.ID, role.Role.ID)
+	if err != nil {
+		log.Println(err)
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I could not give you that role.")
+		return
+	}
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("✅ | You now have the role `%s`.", role.Role.Name))
+}
+
+// IAmNot :
+// Removes a self-assignable role from the author.
+// [role]
+func IAmNot(ctx Context) {
+
+	_, role, ok := fetchSelfRole(ctx)
+	if !ok {
+		return
+	}
+
+	member, err := FetchMember(ctx.Session, ctx.Guild.ID, ctx.Event.Author.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if !Contains(member.Roles, role.Role.ID) {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | You do not have that role.")
+		return
+	}
+
+	err = ctx.Session.GuildMemberRoleRemove(ctx.Guild.ID, member.User.ID, role.Role.ID)
+	if err != nil {
+		log.Println(err)
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I could not remove that role.")
+		return
+	}
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("✅ | You no longer have the role `%s`.", role.Role.Name))
+}
+
+// Roles :
+// Lists the self-assignable roles of the guild by group.
+func Roles(ctx Context) {
+
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, FormatString(FormatSelfRoles(g), "asciidoc"))
+}
+
+// SelfRoleAdd :
+// Makes a role self-assignable.
+// [role]
+func SelfRoleAdd(ctx Context) {
+	updateSelfRoles(ctx, func(g *Guild, role *discordgo.Role, i int) bool {
+		if i != -1 {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | That role is already self-assignable.")
+			return false
+		}
+
+		if !BotCanManageRole(ctx.Session, ctx.Guild.ID, role) {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I cannot assign that role, it must be below my highest role.")
+			return false
+		}
+
+		g.SelfRoles = append(g.SelfRoles, SelfRole{Role: role})
+		return true
+	})
+}
+
+// SelfRoleRemove :
+// Makes a role no longer self-assignable.
+// [role]
+func SelfRoleRemove(ctx Context) {
+	updateSelfRoles(ctx, func(g *Guild, role *discordgo.Role, i int) bool {
+		if i == -1 {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | That role is not self-assignable.")
+			return false
+		}
+
+		g.SelfRoles = append(g.SelfRoles[:i], g.SelfRoles[i+1:]...)
+		return true
+	})
+}
+
+// SelfRoleGroup :
+// Sets the exclusive group of a self-assignable role, or clears it with none.
+// [group|none] [role]
+func SelfRoleGroup(ctx Context) {
+
+	group := strings.ToLower(ctx.Arguments.String("group|none"))
+	if group == "none" {
+		group = ""
+	}
+
+	updateSelfRoles(ctx, func(g *Guild, role *discordgo.Role, i int) bool {
+		if i == -1 {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | That role is not self-assignable.")
+			return false
+		}
+
+		g.SelfRoles[i].Group = group
+		return true
+	})
+}
+
+// updateSelfRoles :
+// Changes the guild's self-assignable roles for the role in the arguments and saves them. The
+// update is given the index of the role among the self-assignable roles, or -1, and returns
+// false to leave them unchanged.
+func updateSelfRoles(ctx Context, update func(g *Guild, role *discordgo.Role, i int) bool) {
+
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	role := ctx.Arguments.Role("role")
+	if !update(&g, role, selfRoleIndex(g, role.ID)) {
+		return
+	}
+
+	err = PackGuildStruct(ctx.Guild.ID, g)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, "✅ | Self-assignable roles updated!")
+}
+
+// fetchSelfRole :
+// Resolves the self-assignable role named in the command arguments, replying if it is not one
+// or if the bot cannot assign it.
+func fetchSelfRole(ctx Context) (Guild, SelfRole, bool) {
+
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return g, SelfRole{}, false
+	}
+
+	if len(ctx.Args) == 0 {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | Please give a role. Run `%sroles` for a list.", g.GuildPrefix))
+		return g, SelfRole{}, false
+	}
+
+	name := strings.Join(ctx.Args, " ")
+
+	id := ""
+	if match := snowflake.FindStringSubmatch(name); match != nil {
+		id = match[1]
+	}
+
+	// An exact ID or name regardless of case wins, so "Dark Red" is never taken as "Red"
+	var roles []*discordgo.Role
+	for _, v := range g.SelfRoles {
+		role, err := ctx.Session.State.Role(ctx.Guild.ID, v.Role.ID)
+		if err == nil && (role.ID == id || strings.EqualFold(role.Name, name)) {
+			roles = append(roles, role)
+			break
+		}
+	}
+
+	// Otherwise falls back to roles named anywhere in the arguments
+	if len(roles) == 0 {
+		roles = FetchMessageContentRoles(ctx, name)
+	}
+
+	for _, role := range roles {
+		i := selfRoleIndex(g, role.ID)
+		if i == -1 {
+			continue
+		}
+
+		if !BotCanManageRole(ctx.Session, ctx.Guild.ID, role) {
+			ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | I cannot assign that role, it is above my highest role.")
+			return g, SelfRole{}, false
+		}
+
+		return g, SelfRole{Role: role, Group: g.SelfRoles[i].Group}, true
+	}
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | That role is not self-assignable. Run `%sroles` for a list.", g.GuildPrefix))
+	return g, SelfRole{}, false
+}
+
+// selfRoleIndex :
+// Returns the index of a self-assignable role, or -1.
+func selfRoleIndex(g Guild, roleID string) int {
+	for i, v := range g.SelfRoles {
+		if v.Role.ID == roleID {
+			return i
+		}
+	}
+	return -1
+}
+
+// FormatSelfRoles :
+// Returns a string of self-assignable roles by group.
+func FormatSelfRoles(g Guild) string {
+	if len(g.SelfRoles) == 0 {
+		return "No self-assignable roles have been set."
+	}
+
+	groups := make(map[string][]string)
+	for _, v := range g.SelfRoles {
+		groups[v.Group] = append(groups[v.Group], v.Role.Name)
+	}
+
+	var names []string
+	for k := range groups {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	str := "== Self-Assignable Roles ==\n\n"
+	for _, k := range names {
+		name := k
+		if len(k) == 0 {
+			name = "Any"
+		} else {
+			name += " (pick one)"
+		}
+
+		sort.Strings(groups[k])
+		str += fmt.Sprintf("%-24s ::   %s\n", name, strings.Join(groups[k], ", "))
+	}
+
+	return strings.TrimSuffix(str, "\n")
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gomodule/redigo/redis"
+)
+
+/**
+ * stickyroles.go
+ * Chase Weaver
+ *
+ * This package handles saving the roles of members who leave and restoring them on rejoin.
+ * The muted role is always restored so leaving cannot be used to escape a mute.
+ *
+ * The roles of every member are kept in redis, seeded when guilds load and refreshed when members
+ * join or change, since the member is gone from the state once they leave.
+ */
+
+// memberRolesBatch is how many members are cached per redis command
+const memberRolesBatch = 1000
+
+// CacheMemberRoles :
+// Saves the current roles of guild members, writing only those whose cached roles changed so
+// reloading a guild does not rewrite every member.
+func CacheMemberRoles(guildID string, members ...*discordgo.Member) {
+	key := memberRolesKey(guildID)
+
+	for i := 0; i < len(members); i += memberRolesBatch {
+		end := i + memberRolesBatch
+		if end > len(members) {
+			end = len(members)
+		}
+
+		ids, roles := redis.Args{}.Add(key), []string{}
+		for _, m := range members[i:end] {
+			if m.User != nil {
+				ids = ids.Add(m.User.ID)
+				roles = append(roles, strings.Join(m.Roles, ","))
+			}
+		}
+
+		if len(roles) == 0 {
+			continue
+		}
+
+		cached, err := redis.Values(p.Do("HMGET", ids...))
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		args := redis.Args{}.Add(key)
+		for j, v := range cached {
+			if old, ok := v.([]byte); !ok || string(old) != roles[j] {
+				args = args.Add(ids[j+1], roles[j])
+			}
+		}
+
+		if len(args) == 1 {
+			continue
+		}
+
+		_, err = p.Do("HMSET", args...)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+	}
+}
+
+// takeMemberRoles :
+// Returns and forgets the cached roles of a member who left.
+func takeMemberRoles(guildID, userID string) ([]string, bool) {
+	key := memberRolesKey(guildID)
+	roles, err := redis.String(p.Do("HGET", key, userID))
+	if err != nil {
+		if err != redis.ErrNil {
+			log.Println(err)
+		}
+		return nil, false
+	}
+
+	_, err = p.Do("HDEL", key, userID)
+	if err != nil {
+		log.Println(err)
+	}
+
+	if len(roles) == 0 {
+		return nil, true
+	}
+	return strings.Split(roles, ","), true
+}
+
+// ForgetMemberRoles :
+// Removes the cached roles of every member of a guild.
+func ForgetMemberRoles(guildID string) {
+	_, err := p.Do("DEL", memberRolesKey(guildID))
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// memberRolesKey :
+// Returns the redis key of the cached roles of a guild's members.
+func memberRolesKey(guildID string) string {
+	return fmt.Sprintf("memberroles:%s", guildID)
+}
+
+// SnapshotStickyRoles :
+// Saves the roles of a leaving member into their guild user. Returns true if anything was saved.
+func SnapshotStickyRoles(g *Guild, u *discordgo.User) bool {
+
+	roles, cached := takeMemberRoles(g.Guild.ID, u.ID)
+
+	user, ok := g.GuildUser[u.ID]
+	if !ok && !cached {
+		return false
+	}
+
+	if !ok {
+		user = RegisterNewUser(u)
+	}
+
+	// Falls back to the member saved with the guild user if their roles were not cached
+	if !cached && user.Member != nil {
+		roles = user.Member.Roles
+	}
+
+	user.StickyRoles = append([]string{}, roles...)
+
+	if user.Muted.IsMuted && g.MutedRole != nil && !Contains(user.StickyRoles, g.MutedRole.ID) {
+		user.StickyRoles = append(user.StickyRoles, g.MutedRole.ID)
+	}
+
+	g.GuildUser[u.ID] = user
+	return true
+}
+
+// RestoreStickyRoles :
+// Gives a rejoining member their saved roles. Only the configured sticky roles are restored
+// (every role if none are configured), while the muted role is restored even if sticky roles
+// are disabled.
+func RestoreStickyRoles(s *discordgo.Session, g *Guild, userID string) {
+
+	user, ok := g.GuildUser[userID]
+	if !ok || len(user.StickyRoles) == 0 {
+		return
+	}
+
+	for _, id := range user.StickyRoles {
+		muted := g.MutedRole != nil && id == g.MutedRole.ID
+
+		if !muted && (!g.StickyRoles.Enabled || !g.StickyRoles.isSticky(id)) {
+			continue
+		}
+
+		role, err := s.State.Role(g.Guild.ID, id)
+		if err != nil || !BotCanManageRole(s, g.Guild.ID, role) {
+			continue
+		}
+
+		err = s.GuildMemberRoleAdd(g.Guild.ID, userID, id)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	user.StickyRoles = nil
+	g.GuildUser[userID] = user
+}
+
+// isSticky :
+// Checks if a role is restored on rejoin.
+func (r StickyRoleSettings) isSticky(roleID string) bool {
+	if len(r.Roles) == 0 {
+		return true
+	}
+
+	for _, v := range r.Roles {
+		if v.ID == roleID {
+			return true
+		}
+	}
+
+	return false
+}
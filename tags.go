@@ -0,0 +1,352 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+/**
+ * tags.go
+ * Chase Weaver
+ *
+ * This package handles tags, custom text commands of a guild. Tags are ran like commands when no
+ * command has their name, and use the welcome template variables of the member running them.
+ */
+
+// TagCooldown is the cooldown (in seconds) of running a tag
+const TagCooldown = 3
+
+// tagEditPermissions let members edit and delete tags they do not own
+var tagEditPermissions = []string{"Bot Owner", "Manage Server"}
+
+func init() {
+	RegisterNewCommand(Command{
+		Name:            "tag",
+		Func:            TagList,
+		Enabled:         true,
+		NSFWOnly:        false,
+		IgnoreSelf:      true,
+		IgnoreBots:      true,
+		Cooldown:        0,
+		RunIn:           []string{"Text"},
+		Aliases:         []string{"tags"},
+		UserPermissions: []string{},
+		Usage:           []string{},
+		Subcommands: []Command{
+			{
+				Name:        "list",
+				Func:        TagList,
+				Description: "Lists the guild's tags.",
+			},
+			{
+				Name:        "info",
+				Func:        TagInfo,
+				Params:      []Param{{Name: "name", Type: ArgString}},
+				Description: "Shows the owner, uses and source of a tag.",
+			},
+			{
+				Name:            "create",
+				Func:            TagCreate,
+				Aliases:         []string{"add"},
+				UserPermissions: []string{"Bot Owner", "Manage Messages"},
+				Params:          []Param{{Name: "name", Type: ArgString}, {Name: "text", Type: ArgRest}},
+				Flags:           []Flag{{Name: "embed", Short: "e"}},
+				Description:     "Creates a tag, sent as an embed with --embed.",
+			},
+			{
+				Name:            "edit",
+				Func:            TagEdit,
+				UserPermissions: []string{"Bot Owner", "Manage Messages"},
+				Params:          []Param{{Name: "name", Type: ArgString}, {Name: "text", Type: ArgRest}},
+				Flags:           []Flag{{Name: "embed", Short: "e"}},
+				Description:     "Edits a tag you own, sent as an embed with --embed.",
+			},
+			{
+				Name:            "delete",
+				Func:            TagDelete,
+				Aliases:         []string{"remove"},
+				UserPermissions: []string{"Bot Owner", "Manage Messages"},
+				Params:          []Param{{Name: "name", Type: ArgString}},
+				Description:     "Deletes a tag you own.",
+			},
+		},
+		Description: "Creates, edits and lists the guild's tags.",
+	})
+}
+
+// TagCommand :
+// Returns the command a tag is ran as.
+func TagCommand(t *Tag) Command {
+	return Command{
+		Name:        t.Name,
+		Func:        RunTag,
+		Enabled:     true,
+		IgnoreSelf:  true,
+		IgnoreBots:  true,
+		Cooldown:    TagCooldown,
+		RunIn:       []string{"Text"},
+		Description: "Sends the tag.",
+	}
+}
+
+// RunTag :
+// Sends the tag named by the command, rendered for the command author, and counts the use.
+func RunTag(ctx Context) {
+
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	t, ok := g.Tags[ctx.Command.Name]
+	if !ok {
+		return
+	}
+
+	member, err := FetchMember(ctx.Session, ctx.Guild.ID, ctx.Event.Author.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	msg, err := RenderTemplate(t.Content, TemplateData(ctx.Guild, member, nil))
+	if err != nil {
+		log.Println(err)
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | The tag `%s` could not be rendered.", t.Name))
+		return
+	}
+
+	if t.Embed {
+		_, err = ctx.Session.ChannelMessageSendEmbed(ctx.Channel.ID, NewEmbed().SetDescription(msg).SetColor(RandomInt(0, 16777215)).Truncate().MessageEmbed)
+	} else {
+		// Tags are written by members, so they may only mention users
+		_, err = ctx.Session.ChannelMessageSend(ctx.Channel.ID, NeutralizeMentions(msg))
+	}
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	_, err = p.Do("HINCRBY", tagUsesKey(ctx.Guild.ID), t.Name, 1)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// TagList :
+// Lists the guild's tags and their uses.
+func TagList(ctx Context) {
+
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if len(g.Tags) == 0 {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | There are no tags yet. Create one with `%stag create <name> <text>`.", g.GuildPrefix))
+		return
+	}
+
+	uses := TagUses(ctx.Guild.ID)
+
+	var tags []string
+	for k := range g.Tags {
+		tags = append(tags, fmt.Sprintf("%-16s::  %d uses", k, uses[k]))
+	}
+	sort.Strings(tags)
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, FormatString("== Tags ==\n\n"+strings.Join(tags, "\n"), "asciidoc"))
+}
+
+// TagInfo :
+// Shows the owner, uses and source of a tag.
+// [name]
+func TagInfo(ctx Context) {
+
+	g, t, ok := fetchTag(ctx)
+	if !ok {
+		return
+	}
+
+	output := "Text"
+	if t.Embed {
+		output = "Embed"
+	}
+
+	ctx.Session.ChannelMessageSendEmbed(ctx.Channel.ID,
+		NewEmbed().
+			SetTitle(fmt.Sprintf("Tag: %s%s", g.GuildPrefix, t.Name)).
+			SetDescription("```\n"+t.Content+"```").
+			AddField("Owner", fmt.Sprintf("<@%s>", t.OwnerID)).
+			AddField("Uses", fmt.Sprintf("%d", TagUses(ctx.Guild.ID)[t.Name])).
+			AddField("Output", output).
+			AddField("Created", t.Created.Format(templateTimeFormat)).
+			SetColor(RandomInt(0, 16777215)).
+			Truncate().MessageEmbed)
+}
+
+// TagCreate :
+// Creates a tag owned by the command author.
+// [name] [text]
+func TagCreate(ctx Context) {
+
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	name := strings.ToLower(ctx.Arguments.String("name"))
+	if reason := g.CommandNameTaken(name); len(reason) != 0 {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, "❌ | "+reason)
+		return
+	}
+
+	text := ctx.Arguments.String("text")
+	if err := ValidateTemplate(text); err != nil {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | Invalid tag: `%s`", err))
+		return
+	}
+
+	if g.Tags == nil {
+		g.Tags = make(map[string]*Tag)
+	}
+
+	g.Tags[name] = &Tag{
+		Name:    name,
+		Content: text,
+		Embed:   ctx.HasFlag("embed"),
+		OwnerID: ctx.Event.Author.ID,
+		Created: time.Now(),
+	}
+
+	err = PackGuildStruct(ctx.Guild.ID, g)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	// Counts from an older tag of the same name are dropped
+	_, err = p.Do("HDEL", tagUsesKey(ctx.Guild.ID), name)
+	if err != nil {
+		log.Println(err)
+	}
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("✅ | Created the tag `%s%s`.", g.GuildPrefix, name))
+}
+
+// TagEdit :
+// Replaces the text of a tag owned by the command author.
+// [name] [text]
+func TagEdit(ctx Context) {
+
+	g, t, ok := fetchTag(ctx)
+	if !ok || !canEditTag(ctx, t) {
+		return
+	}
+
+	text := ctx.Arguments.String("text")
+	if err := ValidateTemplate(text); err != nil {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | Invalid tag: `%s`", err))
+		return
+	}
+
+	t.Content = text
+	t.Embed = ctx.HasFlag("embed")
+
+	err := PackGuildStruct(ctx.Guild.ID, g)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("✅ | Edited the tag `%s`.", t.Name))
+}
+
+// TagDelete :
+// Deletes a tag owned by the command author.
+// [name]
+func TagDelete(ctx Context) {
+
+	g, t, ok := fetchTag(ctx)
+	if !ok || !canEditTag(ctx, t) {
+		return
+	}
+
+	delete(g.Tags, t.Name)
+
+	err := PackGuildStruct(ctx.Guild.ID, g)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	_, err = p.Do("HDEL", tagUsesKey(ctx.Guild.ID), t.Name)
+	if err != nil {
+		log.Println(err)
+	}
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("✅ | Deleted the tag `%s`.", t.Name))
+}
+
+// TagUses :
+// Returns how many times each tag of a guild has been used.
+func TagUses(guildID string) map[string]int {
+	uses, err := redis.IntMap(p.Do("HGETALL", tagUsesKey(guildID)))
+	if err != nil {
+		log.Println(err)
+		return map[string]int{}
+	}
+	return uses
+}
+
+// tagUsesKey :
+// Returns the redis key of a guild's tag use counts.
+func tagUsesKey(guildID string) string {
+	return fmt.Sprintf("tags:%s", guildID)
+}
+
+// fetchTag :
+// Returns the guild and the tag named by the arguments, replying if it does not exist.
+func fetchTag(ctx Context) (Guild, *Tag, bool) {
+
+	g, err := UnpackGuildStruct(ctx.Guild.ID)
+	if err != nil {
+		log.Println(err)
+		return g, nil, false
+	}
+
+	name := strings.ToLower(ctx.Arguments.String("name"))
+	t, ok := g.Tags[name]
+	if !ok {
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | `%s` is not a tag.", name))
+		return g, nil, false
+	}
+
+	return g, t, true
+}
+
+// canEditTag :
+// Checks if the command author owns the tag or may edit every tag, replying if not.
+func canEditTag(ctx Context, t *Tag) bool {
+	if t.OwnerID == ctx.Event.Author.ID {
+		return true
+	}
+
+	for _, v := range tagEditPermissions {
+		if MemberHasPermission(ctx, v) {
+			return true
+		}
+	}
+
+	ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | Only <@%s> or members with Manage Server can change the tag `%s`.", t.OwnerID, t.Name))
+	return false
+}
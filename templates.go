@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+/**
+ * templates.go
+ * Chase Weaver
+ *
+ * This package handles parsing and rendering of welcome and goodbye message templates.
+ *
+ * Templates use Go's text/template syntax with the variables below, i.e.
+ * {{if gt .MEMBER_COUNT 1000}}Wow!{{end}} or {{random "Hi" "Hello" "Hey"}} {{.MEMBER_MENTION}}.
+ * The older $VARIABLE$ form is still accepted and is treated as {{.VARIABLE}}.
+ */
+
+// Default welcome and goodbye templates
+const (
+	DefaultWelcomeMessage      = "Welcome $MEMBER_MENTION$ to $GUILD_NAME$! Enjoy your stay."
+	DefaultGoodbyeMessage      = "Goodbye, `$MEMBER_NAME$`!"
+	DefaultMemberAddMessage    = "✅ | `$MEMBER_NAME$` (ID: $MEMBER_ID$ | Age: $MEMBER_AGE$) has joined the guild.{{if .INVITE_CODE}} Invite: `{{.INVITE_CODE}}`{{end}}"
+	DefaultMemberRemoveMessage = "❌ | `$MEMBER_NAME$` (ID: $MEMBER_ID$ | Age: $MEMBER_AGE$ | Joined At: $MEMBER_JOINED$) has left the guild."
+)
+
+// recentJoinWindow is how long after joining a member is taken to be the newest member
+const recentJoinWindow = time.Minute
+
+// MaxTemplateOutput is the most bytes a template may render, the length of an embed description
+const MaxTemplateOutput = 4096
+
+// errTemplateTooLong stops templates rendering past MaxTemplateOutput
+var errTemplateTooLong = fmt.Errorf("the message is longer than %d characters", MaxTemplateOutput)
+
+// templateTimeFormat is used for every date variable
+const templateTimeFormat = "01/02/06 03:04:05 PM MST"
+
+// templateVariables describes every variable available to templates
+var templateVariables = [][2]string{
+	{"MEMBER_NAME", "Username#1234"},
+	{"MEMBER_USERNAME", "Username"},
+	{"MEMBER_DISCRIMINATOR", "1234"},
+	{"MEMBER_MENTION", "@Member"},
+	{"MEMBER_ID", "Member ID"},
+	{"MEMBER_AVATAR", "Avatar URL"},
+	{"MEMBER_BOT", "true if the member is a bot"},
+	{"MEMBER_AGE", "Account creation date"},
+	{"MEMBER_AGE_HUMAN", "Account age, i.e. 2 years, 3 months"},
+	{"MEMBER_JOINED", "Join date"},
+	{"MEMBER_COUNT", "Number of guild members"},
+	{"JOIN_POSITION", "Member's join position, if known"},
+	{"GUILD_NAME", "Guild name"},
+	{"GUILD_ID", "Guild ID"},
+	{"GUILD_ICON", "Guild icon URL"},
+	{"INVITE_CODE", "Invite code used to join, if known"},
+	{"INVITE_URL", "Invite link used to join, if known"},
+	{"INVITE_INVITER", "Creator of the invite, if known"},
+	{"INVITE_USES", "Uses of the invite, if known"},
+}
+
+// legacyTemplateVariable matches the older $VARIABLE$ form
+var legacyTemplateVariable = regexp.MustCompile(`\$([A-Z_]+)\$`)
+
+// massMention matches @everyone, @here and role mentions
+var massMention = regexp.MustCompile(`@everyone|@here|<@&\d+>`)
+
+// printfWidth matches a format verb with a width or precision given as an argument or of four or
+// more digits, which could render far past MaxTemplateOutput before it is written
+var printfWidth = regexp.MustCompile(`%[^a-zA-Z%]*(\*|[1-9]\d{3,})`)
+
+// templateFuncs available to every template
+var templateFuncs = template.FuncMap{
+	"random": func(choices ...string) string {
+		if len(choices) == 0 {
+			return ""
+		}
+		return choices[RandomInt(0, len(choices))]
+	},
+	"ordinal": func(v interface{}) string {
+		n, ok := v.(int)
+		if !ok {
+			return ""
+		}
+		return Ordinal(n)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"printf": func(format string, args ...interface{}) (string, error) {
+		if printfWidth.MatchString(format) {
+			return "", errors.New("printf widths over 999 are not allowed")
+		}
+		return fmt.Sprintf(format, args...), nil
+	},
+}
+
+// ParseTemplate :
+// Parses a welcome / goodbye template, translating $VARIABLE$ into {{.VARIABLE}}. Loops and
+// nested templates are rejected, since guild members write templates and they could otherwise
+// run for as long as they like.
+func ParseTemplate(text string) (*template.Template, error) {
+	text = legacyTemplateVariable.ReplaceAllString(text, "{{.$1}}")
+
+	t, err := template.New("message").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(t.Templates()) > 1 {
+		return nil, errors.New("defining templates is not allowed")
+	}
+
+	if t.Tree != nil {
+		err = checkTemplateNode(t.Tree.Root)
+	}
+	return t, err
+}
+
+// checkTemplateNode :
+// Returns an error if a template node is or contains a loop or a nested template.
+func checkTemplateNode(node parse.Node) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+
+		for _, v := range n.Nodes {
+			if err := checkTemplateNode(v); err != nil {
+				return err
+			}
+		}
+	case *parse.IfNode:
+		return checkTemplateBranch(n.BranchNode)
+	case *parse.WithNode:
+		return checkTemplateBranch(n.BranchNode)
+	case *parse.RangeNode:
+		return errors.New("range is not allowed")
+	case *parse.TemplateNode:
+		return errors.New("nested templates are not allowed")
+	}
+
+	return nil
+}
+
+// checkTemplateBranch :
+// Checks both lists of an if or with node.
+func checkTemplateBranch(n parse.BranchNode) error {
+	if err := checkTemplateNode(n.List); err != nil {
+		return err
+	}
+	return checkTemplateNode(n.ElseList)
+}
+
+// limitedWriter collects template output, failing once it passes MaxTemplateOutput
+type limitedWriter struct {
+	bytes.Buffer
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.Len()+len(p) > MaxTemplateOutput {
+		return 0, errTemplateTooLong
+	}
+	return w.Buffer.Write(p)
+}
+
+// ValidateTemplate :
+// Checks that a template parses and only uses known variables by rendering it with sample values.
+func ValidateTemplate(text string) error {
+	t, err := ParseTemplate(text)
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string]interface{})
+	for _, v := range templateVariables {
+		data[v[0]] = ""
+	}
+	data["MEMBER_BOT"] = false
+	data["MEMBER_COUNT"] = 1
+	data["JOIN_POSITION"] = 1
+	data["INVITE_USES"] = 0
+
+	return t.Execute(&limitedWriter{}, data)
+}
+
+// RenderTemplate :
+// Renders a template with the given variables, up to MaxTemplateOutput bytes.
+func RenderTemplate(text string, data map[string]interface{}) (string, error) {
+	t, err := ParseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	var w limitedWriter
+	err = t.Execute(&w, data)
+	return w.String(), err
+}
+
+// TemplateData :
+// Returns the template variables of a member of a guild. The invite may be nil if unknown.
+func TemplateData(g *discordgo.Guild, m *discordgo.Member, invite *discordgo.Invite) map[string]interface{} {
+
+	created, err := CreationTime(m.User.ID)
+	if err != nil {
+		log.Println(err)
+	}
+
+	joined := "Unknown"
+	if t, err := time.Parse(time.RFC3339Nano, m.JoinedAt); err == nil {
+		joined = t.Format(templateTimeFormat)
+	}
+
+	data := map[string]interface{}{
+		"MEMBER_NAME":          m.User.Username + "#" + m.User.Discriminator,
+		"MEMBER_USERNAME":      m.User.Username,
+		"MEMBER_DISCRIMINATOR": m.User.Discriminator,
+		"MEMBER_MENTION":       "<@" + m.User.ID + ">",
+		"MEMBER_ID":            m.User.ID,
+		"MEMBER_AVATAR":        m.User.AvatarURL("256"),
+		"MEMBER_BOT":           m.User.Bot,
+		"MEMBER_AGE":           created.Format(templateTimeFormat),
+		"MEMBER_AGE_HUMAN":     HumanizeDuration(time.Since(created)),
+		"MEMBER_JOINED":        joined,
+		"MEMBER_COUNT":         g.MemberCount,
+		"JOIN_POSITION":        "",
+		"GUILD_NAME":           g.Name,
+		"GUILD_ID":             g.ID,
+		"GUILD_ICON":           "",
+		"INVITE_CODE":          "",
+		"INVITE_URL":           "",
+		"INVITE_INVITER":       "",
+		"INVITE_USES":          0,
+	}
+
+	if pos := JoinPosition(g, m); pos != 0 {
+		data["JOIN_POSITION"] = pos
+	}
+
+	if g.Icon != "" {
+		data["GUILD_ICON"] = discordgo.EndpointGuildIcon(g.ID, g.Icon)
+	}
+
+	if invite != nil {
+		data["INVITE_CODE"] = invite.Code
+		data["INVITE_URL"] = "https://discord.gg/" + invite.Code
+		data["INVITE_USES"] = invite.Uses
+
+		if invite.Inviter != nil {
+			data["INVITE_INVITER"] = invite.Inviter.Username + "#" + invite.Inviter.Discriminator
+		}
+	}
+
+	return data
+}
+
+// FormatWelcomeGoodbyeMessage :
+// Renders a welcome / goodbye template for a member. Returns an empty string if the template fails.
+func FormatWelcomeGoodbyeMessage(g *discordgo.Guild, m *discordgo.Member, invite *discordgo.Invite, s string) string {
+	msg, err := RenderTemplate(s, TemplateData(g, m, invite))
+
+	if err != nil {
+		log.Println(err)
+		return ""
+	}
+
+	return msg
+}
+
+// FormatTemplateVariables :
+// Returns a string of every template variable and its description.
+func FormatTemplateVariables() string {
+	var str string
+	for _, v := range templateVariables {
+		str += fmt.Sprintf("%-22s ::   %s\n", v[0], v[1])
+	}
+	return strings.TrimSuffix(str, "\n")
+}
+
+// JoinPosition :
+// Returns the position a member joined the guild at. A member who just joined is the newest, while
+// others are counted from the guild's members, or 0 if they are not all known.
+func JoinPosition(g *discordgo.Guild, m *discordgo.Member) int {
+	joined, err := time.Parse(time.RFC3339Nano, m.JoinedAt)
+	if err == nil && time.Since(joined) < recentJoinWindow {
+		return g.MemberCount
+	}
+
+	// Counting an incomplete member list gives a wrong position that looks right
+	if err != nil || len(g.Members) < g.MemberCount {
+		return 0
+	}
+
+	pos := 0
+	for _, v := range g.Members {
+		t, err := time.Parse(time.RFC3339Nano, v.JoinedAt)
+		if err == nil && !t.After(joined) {
+			pos++
+		}
+	}
+
+	return pos
+}
+
+// NeutralizeMentions :
+// Breaks @everyone, @here and role mentions with a zero width space so they do not ping.
+func NeutralizeMentions(s string) string {
+	return massMention.ReplaceAllStringFunc(s, func(m string) string {
+		if strings.HasPrefix(m, "<@&") {
+			return "<@\u200b&" + m[3:]
+		}
+		return "@\u200b" + m[1:]
+	})
+}
+
+// HumanizeDuration :
+// Formats a duration with its two largest units, i.e. "2 years, 3 months".
+func HumanizeDuration(d time.Duration) string {
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"year", 365 * 24 * time.Hour},
+		{"month", 30 * 24 * time.Hour},
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+
+	var parts []string
+	for _, u := range units {
+		n := int(d / u.size)
+		if n == 0 {
+			continue
+		}
+
+		d -= time.Duration(n) * u.size
+		if n == 1 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, u.name))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d %ss", n, u.name))
+		}
+
+		if len(parts) == 2 {
+			break
+		}
+	}
+
+	if len(parts) == 0 {
+		return "less than a minute"
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Ordinal :
+// Returns a number with its ordinal suffix, i.e. 1st, 22nd, 113th.
+func Ordinal(n int) string {
+	suffix := "th"
+	switch n % 10 {
+	case 1:
+		suffix = "st"
+	case 2:
+		suffix = "nd"
+	case 3:
+		suffix = "rd"
+	}
+
+	if n%100 >= 11 && n%100 <= 13 {
+		suffix = "th"
+	}
+
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// migrateTemplates :
+// Fixes the $MEMBER_NAME& typo of older default templates.
+func (g *Guild) migrateTemplates() {
+	for _, t := range []*string{&g.WelcomeMessage, &g.GoodbyeMessage, &g.MemberAddMessage, &g.MemberRemoveMessage} {
+		*t = strings.Replace(*t, "$MEMBER_NAME&", "$MEMBER_NAME$", -1)
+		*t = strings.Replace(*t, "has joinied the guild", "has joined the guild", -1)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -22,6 +23,9 @@ var (
 	banColor     = 16711684
 	deleteColor  = 4378356
 	editColor    = 4387980
+	createColor  = 4437377
+	updateColor  = 16763904
+	removeColor  = 15746887
 )
 
 // Embed hold the embed struct
@@ -38,6 +42,9 @@ const (
 	EmbedLimitField       = 25
 	EmbedLimitFooter      = 2048
 	EmbedLimit            = 4000
+
+	// Content longer than this is attached as a file in message logs
+	MessageLogContentLimit = 2 * EmbedLimitFieldValue
 )
 
 /**
@@ -93,6 +100,24 @@ func (e *Embed) AddField(name, value string) *Embed {
 
 }
 
+// AddLongField :
+// Adds a field embed to array, splitting values over the field character limit across
+// multiple fields
+// [name] [value]
+func (e *Embed) AddLongField(name, value string) *Embed {
+	parts := SplitString(value, EmbedLimitFieldValue)
+
+	if len(parts) == 1 {
+		return e.AddField(name, value)
+	}
+
+	for i, v := range parts {
+		e.AddField(fmt.Sprintf("%s (%d/%d)", name, i+1, len(parts)), v)
+	}
+
+	return e
+}
+
 // SetFooter :
 // Sets embed footer
 // [iconURL] [text] [proxyURL]
@@ -309,63 +334,142 @@ func FormatString(s string, t string) string {
 	return fmt.Sprintf("```%s\n"+s+"```", t)
 }
 
-// FormatWelcomeGoodbyeMessage :
-// Replaces string contents with User / Guild Names and IDs.
-func FormatWelcomeGoodbyeMessage(g *discordgo.Guild, m *discordgo.Member, s string) string {
-	msg := s
+// SplitString :
+// Splits a string into chunks of at most n bytes, preferring to split on new lines and
+// never splitting a multi-byte character.
+func SplitString(s string, n int) []string {
+	var parts []string
+
+	for len(s) > n {
+		i := n
 
-	// Username#xxxx
-	if strings.Contains(msg, "$MEMBER_NAME$") {
-		msg = strings.Replace(msg, "$MEMBER_NAME$", m.User.Username+"#"+m.User.Discriminator, -1)
+		// Back up to the start of a character
+		for i > 0 && !utf8.RuneStart(s[i]) {
+			i--
+		}
+
+		// Prefer splitting on the last new line of the chunk
+		if j := strings.LastIndex(s[:i], "\n"); j > 0 {
+			i = j + 1
+		}
+
+		parts = append(parts, s[:i])
+		s = s[i:]
 	}
 
-	// @Member
-	if strings.Contains(s, "$MEMBER_MENTION$") {
-		msg = strings.Replace(msg, "$MEMBER_MENTION$", "<@"+m.User.ID+">", -1)
+	return append(parts, s)
+}
+
+// AddMessageContent :
+// Adds the content, attachments, and embeds of a message to a log embed. Content too long
+// for the embed is returned as a .txt file instead, along with any cached image attachments.
+func AddMessageContent(e *Embed, name string, guildID string, m *discordgo.Message, reupload bool) []*discordgo.File {
+	var files []*discordgo.File
+
+	switch {
+	case len(m.Content) == 0:
+		e.AddField(name, "N/A")
+	case len(m.Content) > MessageLogContentLimit:
+		filename := strings.ToLower(strings.Replace(name, " ", "-", -1)) + "-" + m.ID + ".txt"
+		e.AddField(name, fmt.Sprintf("Content is too long (%d characters), attached as `%s`.", len(m.Content), filename))
+		files = append(files, &discordgo.File{
+			Name:   filename,
+			Reader: strings.NewReader(m.Content),
+		})
+	default:
+		e.AddLongField(name, m.Content)
+	}
+
+	if len(m.Attachments) != 0 {
+		var str []string
+		for _, a := range m.Attachments {
+			str = append(str, fmt.Sprintf("[%s](%s)", a.Filename, a.URL))
+		}
+		e.AddLongField("Attachments", strings.Join(str, "\n"))
 	}
 
-	// Member ID
-	if strings.Contains(s, "$MEMBER_ID$") {
-		msg = strings.Replace(msg, "$MEMBER_ID$", m.User.ID, -1)
+	if len(m.Embeds) != 0 {
+		var str []string
+		for _, v := range m.Embeds {
+			title := v.Title
+			if title == "" {
+				title = v.URL
+			}
+			if title == "" {
+				title = "Untitled"
+			}
+			str = append(str, fmt.Sprintf("%s: %s", strings.Title(string(v.Type)), title))
+		}
+		e.AddLongField("Embeds", strings.Join(str, "\n"))
 	}
 
-	// Member Age
-	if strings.Contains(s, "$MEMBER_AGE$") {
+	// Re-upload cached images, showing the first one in the embed
+	if reupload {
+		cached := FetchCachedAttachments(guildID, m.ID)
 
-		// Fetch creation time of user
-		t, err := CreationTime(m.User.ID)
+		if len(cached) != 0 {
+			e.SetImage("attachment://" + cached[0].Name)
+		}
 
-		if err != nil {
-			log.Println(err)
+		files = append(files, cached...)
+	}
+
+	return files
+}
+
+// MessageChanged :
+// Checks if an edit changed the content, attachments, or embeds of a logged message.
+func MessageChanged(old *discordgo.Message, edited *discordgo.Message) bool {
+
+	if old.Content != edited.Content || len(old.Attachments) != len(edited.Attachments) || len(old.Embeds) != len(edited.Embeds) {
+		return true
+	}
+
+	for i, v := range old.Attachments {
+		if v.ID != edited.Attachments[i].ID {
+			return true
 		}
+	}
 
-		msg = strings.Replace(msg, "$MEMBER_AGE$", t.Format("01/02/06 03:04:05 PM MST"), -1)
+	for i, v := range old.Embeds {
+		e := edited.Embeds[i]
+		if v.URL != e.URL || v.Title != e.Title || v.Description != e.Description {
+			return true
+		}
 	}
 
-	// Member Joined
-	if strings.Contains(s, "$MEMBER_JOINED$") {
+	return false
+}
 
-		// Fetch joined time of user
-		t, err := time.Parse(time.RFC3339Nano, m.JoinedAt)
+// FormatTranscript :
+// Returns a plain text transcript of messages with their author, time, content, and attachments.
+func FormatTranscript(messages []*discordgo.Message) string {
+	str := ""
+
+	for _, m := range messages {
+		t, err := CreationTime(m.ID)
 
 		if err != nil {
 			log.Println(err)
 		}
 
-		msg = strings.Replace(msg, "$MEMBER_JOINED$", t.Format("01/02/06 03:04:05 PM MST"), -1)
-	}
+		str += fmt.Sprintf("[%s] %s#%s (%s): %s\n",
+			t.Format("01/02/06 03:04:05 PM MST"), m.Author.Username, m.Author.Discriminator, m.Author.ID, m.Content)
 
-	// Guild Name
-	if strings.Contains(s, "$GUILD_NAME$") {
-		msg = strings.Replace(msg, "$GUILD_NAME$", g.Name, -1)
+		for _, a := range m.Attachments {
+			str += fmt.Sprintf("    Attachment: %s (%s)\n", a.Filename, a.URL)
+		}
+
+		for _, v := range m.Embeds {
+			str += fmt.Sprintf("    Embed: %s %s\n", v.Title, v.URL)
+		}
 	}
 
-	// Guild ID
-	if strings.Contains(s, "$GUILD_ID$") {
-		msg = strings.Replace(msg, "$GUILD_ID$", g.ID, -1)
+	if str == "" {
+		str = "None of the deleted messages were cached.\n"
 	}
 
-	return msg
+	return str
 }
 
 // TrimSuffix :
@@ -378,11 +482,9 @@ func TrimSuffix(s, suffix string) string {
 }
 
 // DeleteMessageWithTime :
-// Deletes a message by ID after a given time in milliseconds.
+// Queues a message to be deleted after a given time in milliseconds, without waiting.
 func DeleteMessageWithTime(ctx Context, ID string, t float32) {
-	time.Sleep(time.Duration(t) * time.Millisecond)
-
-	err := ctx.Session.ChannelMessageDelete(ctx.Channel.ID, ID)
+	err := ScheduleDeletion(ctx.Channel.ID, ID, time.Duration(t)*time.Millisecond)
 
 	if err != nil {
 		log.Println(err)
@@ -415,7 +517,7 @@ func LogCommands(ctx Context) {
 				"\n\n",
 			ctx.Event.Author.Username+"#"+ctx.Event.Author.Discriminator, ctx.Event.Author.ID,
 			ctx.Guild.Name, ctx.Guild.ID, ctx.Channel.Name, ctx.Channel.ID,
-			ctx.Name, len(ctx.Args), ctx.Args)
+			ctx.Command.Path(), len(ctx.Args), ctx.Args)
 	} else {
 		log.Printf(
 			"\n"+
@@ -425,7 +527,7 @@ func LogCommands(ctx Context) {
 				"Args:      %s"+
 				"\n\n",
 			ctx.Event.Author.Username+"#"+ctx.Event.Author.Discriminator,
-			ctx.Event.Author.ID, ctx.Channel.ID, ctx.Name, ctx.Args)
+			ctx.Event.Author.ID, ctx.Channel.ID, ctx.Command.Path(), ctx.Args)
 	}
 }
 
@@ -470,6 +572,59 @@ func CreationTime(ID string) (t time.Time, err error) {
 	return
 }
 
+// FetchMember :
+// Returns a guild member from the state, or from Discord if it is not cached.
+func FetchMember(s *discordgo.Session, guildID, userID string) (*discordgo.Member, error) {
+	member, err := s.State.Member(guildID, userID)
+	if err == nil {
+		return member, nil
+	}
+	return s.GuildMember(guildID, userID)
+}
+
+// BotCanManageRole :
+// Checks if the bot has permission to manage roles and its highest role is above the given role.
+func BotCanManageRole(s *discordgo.Session, guildID string, role *discordgo.Role) bool {
+	return MemberCanManageRole(s, guildID, s.State.User.ID, role)
+}
+
+// MemberCanManageRole :
+// Checks if a member has permission to manage roles and their highest role is above the given role.
+func MemberCanManageRole(s *discordgo.Session, guildID, userID string, role *discordgo.Role) bool {
+	if role.Managed || role.ID == guildID {
+		return false
+	}
+
+	if guild, err := s.State.Guild(guildID); err == nil && guild.OwnerID == userID {
+		return true
+	}
+
+	member, err := FetchMember(s, guildID, userID)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	var perms, highest int
+	for _, id := range member.Roles {
+		r, err := s.State.Role(guildID, id)
+		if err != nil {
+			continue
+		}
+
+		perms |= r.Permissions
+		if r.Position > highest {
+			highest = r.Position
+		}
+	}
+
+	if perms&(discordgo.PermissionManageRoles|discordgo.PermissionAdministrator) == 0 {
+		return false
+	}
+
+	return highest > role.Position
+}
+
 // FetchMessageContentUsers :
 // Returns an array of Discord Users found within a string by ID / Name / Mention (guild restriction).
 func FetchMessageContentUsers(ctx Context, msg string) []*discordgo.User {
@@ -477,13 +632,12 @@ func FetchMessageContentUsers(ctx Context, msg string) []*discordgo.User {
 	re := regexp.MustCompile("([0-9]{18,18})")
 
 	for _, v := range re.FindAllString(msg, -1) {
-		usr, err := ctx.Session.User(v)
-
-		if err != nil {
+		usr := resolveUser(ctx, v)
+		if usr == nil {
 			break
 		}
-		_, err = ctx.Session.GuildMember(ctx.Guild.ID, usr.ID)
 
+		_, err := FetchMember(ctx.Session, ctx.Guild.ID, usr.ID)
 		if err != nil {
 			break
 		}
@@ -518,14 +672,12 @@ func FetchMessageContentUsersString(ctx Context, str string) ([]*discordgo.User,
 
 	// Add members by ID from regexp, removes IDs from message string
 	for _, v := range re.FindAllString(msg, -1) {
-		usr, err := ctx.Session.User(v)
-
-		if err != nil {
+		usr := resolveUser(ctx, v)
+		if usr == nil {
 			break
 		}
 
-		_, err = ctx.Session.GuildMember(ctx.Guild.ID, usr.ID)
-
+		_, err := FetchMember(ctx.Session, ctx.Guild.ID, usr.ID)
 		if err != nil {
 			break
 		}
@@ -564,9 +716,8 @@ func FetchMessageContentUsersAllGuilds(ctx Context, msg string) []*discordgo.Use
 	re := regexp.MustCompile("([0-9]{18,18})")
 
 	for _, v := range re.FindAllString(msg, -1) {
-		usr, err := ctx.Session.User(v)
-
-		if err != nil {
+		usr := resolveUser(ctx, v)
+		if usr == nil {
 			break
 		}
 
@@ -580,7 +731,7 @@ func FetchMessageContentUsersAllGuilds(ctx Context, msg string) []*discordgo.Use
 func FetchMessageContentChannels(ctx Context, msg string) []*discordgo.Channel {
 	var arr []*discordgo.Channel
 	re := regexp.MustCompile("([0-9]{18,18})")
-	channel, err := ctx.Session.GuildChannels(ctx.Guild.ID)
+	g, err := ctx.Session.State.Guild(ctx.Guild.ID)
 
 	if err != nil {
 		return arr
@@ -588,15 +739,13 @@ func FetchMessageContentChannels(ctx Context, msg string) []*discordgo.Channel {
 
 	// Add channels by ID/Mention
 	for _, v := range re.FindAllString(msg, -1) {
-		for _, c := range channel {
-			if v == c.ID {
-				arr = append(arr, c)
-			}
+		if c := resolveChannel(ctx, v); c != nil {
+			arr = append(arr, c)
 		}
 	}
 
 	// Add channel by name, case sensitive
-	for _, c := range channel {
+	for _, c := range g.Channels {
 		if strings.Contains(msg, c.Name) {
 			arr = append(arr, c)
 		}
@@ -610,7 +759,7 @@ func FetchMessageContentChannels(ctx Context, msg string) []*discordgo.Channel {
 func FetchMessageContentRoles(ctx Context, msg string) []*discordgo.Role {
 	var arr []*discordgo.Role
 	re := regexp.MustCompile("([0-9]{18,18})")
-	role, err := ctx.Session.GuildRoles(ctx.Guild.ID)
+	g, err := ctx.Session.State.Guild(ctx.Guild.ID)
 
 	if err != nil {
 		return arr
@@ -618,15 +767,13 @@ func FetchMessageContentRoles(ctx Context, msg string) []*discordgo.Role {
 
 	// Add role by ID/Mention
 	for _, v := range re.FindAllString(msg, -1) {
-		for _, r := range role {
-			if v == r.ID {
-				arr = append(arr, r)
-			}
+		if r := resolveRole(ctx, v); r != nil {
+			arr = append(arr, r)
 		}
 	}
 
 	// Add role by name, case sensitive
-	for _, r := range role {
+	for _, r := range g.Roles {
 		if strings.Contains(msg, r.Name) {
 			arr = append(arr, r)
 		}
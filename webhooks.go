@@ -0,0 +1,218 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+/**
+ * webhooks.go
+ * Chase Weaver
+ *
+ * This package handles delivering guild logs through webhooks managed by the
+ * bot, batching several embeds into each webhook message.
+ */
+
+// Webhook log delivery configuration
+const (
+	LogWebhookName      = "Nagato Logs"
+	LogBatchInterval    = 2 * time.Second
+	LogBatchMaxEmbeds   = 10
+	LogBatchMaxEmbedLen = 6000
+)
+
+// logBatch of embeds waiting to be sent through a channel's webhook
+type logBatch struct {
+	webhook *discordgo.Webhook
+	embeds  []*discordgo.MessageEmbed
+	length  int
+	timer   *time.Timer
+}
+
+var (
+	logWebhooks   = make(map[string]*discordgo.Webhook)
+	logBatches    = make(map[string]*logBatch)
+	logWebhooksMu sync.Mutex
+)
+
+// FetchLogWebhook :
+// Returns the bot's log webhook for a channel, reusing an existing one or creating it if missing.
+// The lock is only held to read and store the cached webhook, never during REST calls.
+func FetchLogWebhook(s *discordgo.Session, channelID string) (*discordgo.Webhook, error) {
+
+	logWebhooksMu.Lock()
+	w, ok := logWebhooks[channelID]
+	logWebhooksMu.Unlock()
+
+	if ok {
+		return w, nil
+	}
+
+	hooks, err := s.ChannelWebhooks(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	w = nil
+	for _, v := range hooks {
+		if v.Name == LogWebhookName && v.User != nil && v.User.ID == s.State.User.ID && v.Token != "" {
+			w = v
+			break
+		}
+	}
+
+	if w == nil {
+		w, err = s.WebhookCreate(channelID, LogWebhookName, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Another log may have found or created a webhook meanwhile, the first one stored is kept
+	logWebhooksMu.Lock()
+	defer logWebhooksMu.Unlock()
+
+	if cached, ok := logWebhooks[channelID]; ok {
+		return cached, nil
+	}
+
+	logWebhooks[channelID] = w
+	return w, nil
+}
+
+// QueueWebhookLog :
+// Adds an embed to a channel's pending webhook log batch, sending the batch once it is full
+// or after the batch interval.
+func QueueWebhookLog(s *discordgo.Session, channelID string, e *discordgo.MessageEmbed) error {
+
+	w, err := FetchLogWebhook(s, channelID)
+	if err != nil {
+		return err
+	}
+
+	logWebhooksMu.Lock()
+
+	length := EmbedLength(e)
+	batch, ok := logBatches[channelID]
+
+	// Take the pending batch to send first if the embed does not fit in it
+	var full *logBatch
+	if ok && (len(batch.embeds) >= LogBatchMaxEmbeds || batch.length+length > LogBatchMaxEmbedLen) {
+		full = takeLogBatch(channelID)
+		ok = false
+	}
+
+	if !ok {
+		batch = &logBatch{webhook: w}
+		batch.timer = time.AfterFunc(LogBatchInterval, func() {
+			logWebhooksMu.Lock()
+			var due *logBatch
+			if logBatches[channelID] == batch {
+				due = takeLogBatch(channelID)
+			}
+			logWebhooksMu.Unlock()
+
+			sendLogBatch(s, channelID, due)
+		})
+		logBatches[channelID] = batch
+	}
+
+	batch.embeds = append(batch.embeds, e)
+	batch.length += length
+
+	logWebhooksMu.Unlock()
+
+	sendLogBatch(s, channelID, full)
+	return nil
+}
+
+// takeLogBatch :
+// Removes a channel's pending webhook log batch so it can be sent without holding the lock.
+// Must be called with logWebhooksMu held.
+func takeLogBatch(channelID string) *logBatch {
+
+	batch, ok := logBatches[channelID]
+	if !ok {
+		return nil
+	}
+
+	delete(logBatches, channelID)
+	batch.timer.Stop()
+
+	return batch
+}
+
+// sendLogBatch :
+// Sends a webhook log batch taken from a channel. Falls back to sending the embeds as the bot
+// if the webhook is no longer usable. Must be called without logWebhooksMu held, so a slow
+// channel does not hold up the logs of every other channel.
+func sendLogBatch(s *discordgo.Session, channelID string, batch *logBatch) {
+
+	if batch == nil {
+		return
+	}
+
+	err := s.WebhookExecute(batch.webhook.ID, batch.webhook.Token, false, &discordgo.WebhookParams{
+		Username:  s.State.User.Username,
+		AvatarURL: s.State.User.AvatarURL("256"),
+		Embeds:    batch.embeds,
+	})
+
+	if err == nil {
+		return
+	}
+
+	// The webhook was most likely deleted, a new one is created on the next log
+	log.Println(err)
+
+	logWebhooksMu.Lock()
+	if w, ok := logWebhooks[channelID]; ok && w.ID == batch.webhook.ID {
+		delete(logWebhooks, channelID)
+	}
+	logWebhooksMu.Unlock()
+
+	for _, e := range batch.embeds {
+		_, err := s.ChannelMessageSendEmbed(channelID, e)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// FlushWebhookLogs :
+// Sends every pending webhook log batch.
+func FlushWebhookLogs(s *discordgo.Session) {
+	logWebhooksMu.Lock()
+	batches := make(map[string]*logBatch)
+	for channelID := range logBatches {
+		batches[channelID] = takeLogBatch(channelID)
+	}
+	logWebhooksMu.Unlock()
+
+	for channelID, batch := range batches {
+		sendLogBatch(s, channelID, batch)
+	}
+}
+
+// EmbedLength :
+// Returns the number of characters of an embed counted towards Discord's total embed limit.
+func EmbedLength(e *discordgo.MessageEmbed) int {
+	length := len(e.Title) + len(e.Description)
+
+	for _, v := range e.Fields {
+		length += len(v.Name) + len(v.Value)
+	}
+
+	if e.Footer != nil {
+		length += len(e.Footer.Text)
+	}
+
+	if e.Author != nil {
+		length += len(e.Author.Name)
+	}
+
+	return length
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"log"
+	"net/http"
+
+	"github.com/bwmarrin/discordgo"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+/**
+ * welcome.go
+ * Chase Weaver
+ *
+ * This package handles sending welcome messages as plain text, embeds, or rendered PNG cards.
+ */
+
+// Welcome message modes
+const (
+	WelcomeModeText  = "text"
+	WelcomeModeEmbed = "embed"
+	WelcomeModeCard  = "card"
+)
+
+// Welcome card layout
+const (
+	welcomeCardWidth  = 800
+	welcomeCardHeight = 260
+	welcomeCardAvatar = 192
+	welcomeCardMargin = 34
+)
+
+var (
+	welcomeCardBackground = color.RGBA{0x23, 0x27, 0x2a, 0xff}
+	welcomeCardText       = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	welcomeCardSubtext    = color.RGBA{0x99, 0xaa, 0xb5, 0xff}
+)
+
+// SendWelcomeMessage :
+// Sends the welcome message of a guild in its configured mode to the welcome channel.
+func SendWelcomeMessage(s *discordgo.Session, g Guild, guild *discordgo.Guild, m *discordgo.Member, invite *discordgo.Invite) error {
+
+	data := TemplateData(guild, m, invite)
+
+	msg, err := RenderTemplate(g.WelcomeMessage, data)
+	if err != nil {
+		return err
+	}
+
+	switch g.WelcomeMode {
+	case WelcomeModeEmbed:
+		e, err := WelcomeEmbed(g.WelcomeEmbed, msg, data)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.ChannelMessageSendEmbed(g.WelcomeChannel.ID, e.SetThumbnail(m.User.AvatarURL("256")).MessageEmbed)
+		return err
+	case WelcomeModeCard:
+		card, err := RenderWelcomeCard(m.User, JoinPosition(guild, m), g.WelcomeEmbed.Color)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.ChannelMessageSendComplex(g.WelcomeChannel.ID, &discordgo.MessageSend{
+			Content: msg,
+			Files:   []*discordgo.File{{Name: "welcome.png", ContentType: "image/png", Reader: card}},
+		})
+		return err
+	}
+
+	if len(msg) == 0 {
+		return nil
+	}
+
+	_, err = s.ChannelMessageSend(g.WelcomeChannel.ID, msg)
+	return err
+}
+
+// SendWelcomeDM :
+// Sends the welcome direct message of a guild to a new member.
+func SendWelcomeDM(s *discordgo.Session, g Guild, guild *discordgo.Guild, m *discordgo.Member, invite *discordgo.Invite) error {
+
+	msg, err := RenderTemplate(g.WelcomeDMMessage, TemplateData(guild, m, invite))
+	if err != nil || len(msg) == 0 {
+		return err
+	}
+
+	ch, err := s.UserChannelCreate(m.User.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ChannelMessageSend(ch.ID, msg)
+	return err
+}
+
+// WelcomeEmbed :
+// Builds the welcome embed, falling back to the welcome message when no description is set.
+func WelcomeEmbed(w WelcomeEmbedSettings, msg string, data map[string]interface{}) (*Embed, error) {
+
+	title, err := RenderTemplate(w.Title, data)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := msg
+	if len(w.Description) != 0 {
+		desc, err = RenderTemplate(w.Description, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewEmbed().
+		SetTitle(title).
+		SetDescription(desc).
+		SetColor(w.Color).
+		Truncate(), nil
+}
+
+// RenderWelcomeCard :
+// Renders a PNG welcome card with the user's avatar, name and member number.
+// Only ASCII and Latin-1 characters of the name are drawn.
+func RenderWelcomeCard(u *discordgo.User, position int, clr int) (*bytes.Buffer, error) {
+
+	card := image.NewRGBA(image.Rect(0, 0, welcomeCardWidth, welcomeCardHeight))
+	draw.Draw(card, card.Bounds(), image.NewUniform(welcomeCardBackground), image.ZP, draw.Src)
+
+	accent := color.RGBA{uint8(clr >> 16), uint8(clr >> 8), uint8(clr), 0xff}
+	if clr == 0 {
+		accent = color.RGBA{0x72, 0x89, 0xda, 0xff}
+	}
+	draw.Draw(card, image.Rect(0, 0, 12, welcomeCardHeight), image.NewUniform(accent), image.ZP, draw.Src)
+
+	// Draw the avatar as a circle, the card is still sent if it cannot be fetched
+	top := (welcomeCardHeight - welcomeCardAvatar) / 2
+	avatar, err := fetchAvatar(u)
+	if err != nil {
+		log.Println(err)
+	} else {
+		scaled := image.NewRGBA(image.Rect(0, 0, welcomeCardAvatar, welcomeCardAvatar))
+		xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), avatar, avatar.Bounds(), draw.Src, nil)
+
+		r := image.Rect(welcomeCardMargin, top, welcomeCardMargin+welcomeCardAvatar, top+welcomeCardAvatar)
+		draw.DrawMask(card, r, scaled, image.ZP, circleMask{welcomeCardAvatar / 2}, image.ZP, draw.Over)
+	}
+
+	x := welcomeCardMargin*2 + welcomeCardAvatar
+	drawCardText(card, "WELCOME", x, top+8, 3, accent)
+	drawCardText(card, u.Username+"#"+u.Discriminator, x, top+70, 4, welcomeCardText)
+	if position != 0 {
+		drawCardText(card, fmt.Sprintf("Member #%d", position), x, top+140, 3, welcomeCardSubtext)
+	}
+
+	var buf bytes.Buffer
+	err = png.Encode(&buf, card)
+	return &buf, err
+}
+
+// fetchAvatar :
+// Downloads and decodes a user's avatar.
+func fetchAvatar(u *discordgo.User) (image.Image, error) {
+	resp, err := http.Get(u.AvatarURL("256"))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching avatar: %s", resp.Status)
+	}
+
+	img, _, err := image.Decode(resp.Body)
+	return img, err
+}
+
+// drawCardText :
+// Draws text with its top left corner at (x, y), scaling the bitmap font by scale.
+// The text is cut off at the right edge of the card.
+func drawCardText(dst *image.RGBA, text string, x, y, scale int, clr color.Color) {
+	face := basicfont.Face7x13
+
+	// Cut the text to the space left on the card
+	max := (dst.Bounds().Dx() - x - welcomeCardMargin) / (face.Advance * scale)
+	if runes := []rune(text); len(runes) > max {
+		text = string(runes[:max-3]) + "..."
+	}
+
+	width := font.MeasureString(face, text).Ceil()
+	tmp := image.NewRGBA(image.Rect(0, 0, width, face.Height))
+
+	d := &font.Drawer{
+		Dst:  tmp,
+		Src:  image.NewUniform(clr),
+		Face: face,
+		Dot:  fixed.P(0, face.Ascent),
+	}
+	d.DrawString(text)
+
+	r := image.Rect(x, y, x+width*scale, y+face.Height*scale)
+	xdraw.NearestNeighbor.Scale(dst, r, tmp, tmp.Bounds(), draw.Over, nil)
+}
+
+// circleMask is an alpha mask of a circle with radius r
+type circleMask struct {
+	r int
+}
+
+func (c circleMask) ColorModel() color.Model {
+	return color.AlphaModel
+}
+
+func (c circleMask) Bounds() image.Rectangle {
+	return image.Rect(0, 0, c.r*2, c.r*2)
+}
+
+func (c circleMask) At(x, y int) color.Color {
+	dx, dy := x-c.r, y-c.r
+	if dx*dx+dy*dy <= c.r*c.r {
+		return color.Alpha{255}
+	}
+	return color.Alpha{0}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+/**
+ * workers.go
+ * Chase Weaver
+ *
+ * This package handles running commands on a bounded pool of workers, each command with its own
+ * deadline that is also cancelled when the bot shuts down.
+ */
+
+// Command worker defaults
+const (
+	DefaultCommandTimeout = 30 * time.Second
+	DefaultCommandWorkers = 16
+	LongCommandWorkers    = 2
+	LongCommandTimeout    = time.Minute
+	CommandQueueSize      = 64
+	CommandStopGrace      = 2 * time.Second
+	ShutdownGracePeriod   = 10 * time.Second
+)
+
+var (
+	botCtx, stopBot  = context.WithCancel(context.Background())
+	commandQueue     = make(chan Context, CommandQueueSize)
+	longCommandQueue = make(chan Context, CommandQueueSize)
+	commandWorkers   sync.WaitGroup
+)
+
+// StartCommandWorkers :
+// Starts the workers that run queued commands, CommandWorkers from the config or the default,
+// and the workers that run commands with a timeout over LongCommandTimeout, so long jobs such as
+// bulk role changes cannot take up the workers of every other command.
+func StartCommandWorkers() {
+	n := conf.CommandWorkers
+	if n <= 0 {
+		n = DefaultCommandWorkers
+	}
+
+	startWorkers(commandQueue, n)
+	startWorkers(longCommandQueue, LongCommandWorkers)
+}
+
+// startWorkers :
+// Starts n workers running the commands of a queue until the bot shuts down.
+func startWorkers(queue chan Context, n int) {
+	for i := 0; i < n; i++ {
+		commandWorkers.Add(1)
+
+		go func() {
+			defer commandWorkers.Done()
+
+			for {
+				select {
+				case ctx := <-queue:
+					RunWithDeadline(ctx)
+				case <-botCtx.Done():
+					return
+				}
+			}
+		}()
+	}
+}
+
+// QueueCommand :
+// Queues a command for the workers. Returns false if the queue is full or the bot is shutting down.
+func QueueCommand(ctx Context) bool {
+	if botCtx.Err() != nil {
+		return false
+	}
+
+	queue := commandQueue
+	if CommandTimeout(ctx.Command) > LongCommandTimeout {
+		queue = longCommandQueue
+	}
+
+	select {
+	case queue <- ctx:
+		return true
+	default:
+		return false
+	}
+}
+
+// CommandTimeout :
+// Returns the timeout of a command, the config's CommandTimeout (in seconds) or the default.
+func CommandTimeout(c Command) time.Duration {
+	if c.Timeout != 0 {
+		return c.Timeout
+	}
+
+	if conf.CommandTimeout > 0 {
+		return time.Duration(conf.CommandTimeout) * time.Second
+	}
+
+	return DefaultCommandTimeout
+}
+
+// RunWithDeadline :
+// Runs a command through the middleware chain with its timeout. Once the deadline passes the
+// command is replied to as timed out unless it stops on its own shortly after, while the worker
+// keeps its slot until the command returns, so slow commands cannot grow past the pool.
+func RunWithDeadline(ctx Context) {
+	timeout := CommandTimeout(ctx.Command)
+
+	var cancel context.CancelFunc
+	ctx.Ctx, cancel = context.WithTimeout(botCtx, timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Dispatch(ctx)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-ctx.Ctx.Done():
+	}
+
+	select {
+	case <-done:
+		return
+	case <-time.After(CommandStopGrace):
+	}
+
+	if ctx.Ctx.Err() == context.DeadlineExceeded {
+		log.Printf("command %s timed out after %s", ctx.Command.Path(), timeout)
+		ctx.Session.ChannelMessageSend(ctx.Channel.ID, fmt.Sprintf("❌ | `%s` timed out after %s.", ctx.Command.Path(), timeout))
+	}
+
+	<-done
+}
+
+// StopCommandWorkers :
+// Cancels running commands, stops taking new ones and waits for the workers to finish, up to
+// the grace period.
+func StopCommandWorkers(grace time.Duration) {
+	stopBot()
+
+	done := make(chan struct{})
+	go func() {
+		commandWorkers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(grace):
+		log.Println("command workers did not stop within", grace)
+	}
+}
+
+// Done :
+// Returns a channel that is closed once the command's deadline passes or the bot shuts down.
+// Outside of a command it is nil and never closes.
+func (ctx Context) Done() <-chan struct{} {
+	if ctx.Ctx == nil {
+		return nil
+	}
+	return ctx.Ctx.Done()
+}
+
+// Err :
+// Returns why the command was cancelled, or nil while it may keep running. Loops making REST
+// calls check it to stop once the command times out or the bot shuts down.
+func (ctx Context) Err() error {
+	if ctx.Ctx == nil {
+		return nil
+	}
+	return ctx.Ctx.Err()
+}